@@ -0,0 +1,252 @@
+package filesystem
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+// AccessLogFormat is a line format of NewAccessLogWriter.
+type AccessLogFormat int
+
+const (
+	// AccessLogCommon is the Common Log Format.
+	AccessLogCommon AccessLogFormat = iota
+	// AccessLogCombined is the Combined Log Format, the Common Log Format
+	// followed by the referer and the user agent.
+	AccessLogCombined
+	// AccessLogJSON is a JSON object per request.
+	AccessLogJSON
+)
+
+// clfTimeFormat is the time format of the Common Log Format.
+const clfTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// AccessLogEntry is a request handled, as sent to the AccessLogSink of
+// WithAccessLog.
+type AccessLogEntry struct {
+	Time       time.Time
+	RemoteAddr string
+	Method     string
+	// URI is the request URI, with its query.
+	URI      string
+	Protocol string
+	// Mount is the path prefix of the handler.
+	Mount string
+	// File is the path in the root of the file served, empty if none was,
+	// and Size its size.
+	File   string
+	Size   int64
+	Status int
+	// Bytes is the size of the response body.
+	Bytes int64
+	// Duration is the time spent handling the request. The body of the
+	// file is streamed after it.
+	Duration time.Duration
+	// CacheHit is true if the client was answered to use its cached copy.
+	CacheHit bool
+	// Encoding is the Content-Encoding of the response.
+	Encoding string
+	// Range is the Range header of the request.
+	Range string
+	// Principal is the user the request was made by, from the function of
+	// WithPrincipal.
+	Principal string
+	Referer   string
+	UserAgent string
+}
+
+// AccessLogSink receives the entries of the access log. Log is called
+// concurrently.
+type AccessLogSink interface {
+	Log(entry *AccessLogEntry)
+}
+
+// AccessLogSinkFunc adapts a function to AccessLogSink.
+type AccessLogSinkFunc func(entry *AccessLogEntry)
+
+// Log calls f(entry).
+func (f AccessLogSinkFunc) Log(entry *AccessLogEntry) {
+	f(entry)
+}
+
+// Format formats the entry as a line, without newline, in format.
+func (e *AccessLogEntry) Format(format AccessLogFormat) string {
+	if format == AccessLogJSON {
+		b, _ := json.Marshal(struct {
+			Time       time.Time `json:"time"`
+			RemoteAddr string    `json:"remote_addr"`
+			Method     string    `json:"method"`
+			URI        string    `json:"uri"`
+			Protocol   string    `json:"protocol"`
+			Mount      string    `json:"mount"`
+			File       string    `json:"file,omitempty"`
+			Size       int64     `json:"size,omitempty"`
+			Status     int       `json:"status"`
+			Bytes      int64     `json:"bytes"`
+			DurationMs float64   `json:"duration_ms"`
+			CacheHit   bool      `json:"cache_hit"`
+			Encoding   string    `json:"encoding,omitempty"`
+			Range      string    `json:"range,omitempty"`
+			Principal  string    `json:"principal,omitempty"`
+			Referer    string    `json:"referer,omitempty"`
+			UserAgent  string    `json:"user_agent,omitempty"`
+		}{
+			e.Time, e.RemoteAddr, e.Method, e.URI, e.Protocol, e.Mount, e.File, e.Size, e.Status, e.Bytes,
+			float64(e.Duration) / float64(time.Millisecond), e.CacheHit, e.Encoding, e.Range,
+			e.Principal, e.Referer, e.UserAgent,
+		})
+		return string(b)
+	}
+
+	var b strings.Builder
+	b.WriteString(clfField(e.RemoteAddr))
+	b.WriteString(" - ")
+	b.WriteString(clfField(e.Principal))
+	b.WriteString(" [")
+	b.WriteString(e.Time.Format(clfTimeFormat))
+	b.WriteString(`] "`)
+	requestLine := e.Method + " " + e.URI
+	if e.Protocol != "" {
+		requestLine += " " + e.Protocol
+	}
+	b.WriteString(clfEscape(requestLine))
+	b.WriteString(`" `)
+	b.WriteString(strconv.Itoa(e.Status))
+	b.WriteByte(' ')
+	if e.Bytes > 0 {
+		b.WriteString(strconv.FormatInt(e.Bytes, 10))
+	} else {
+		b.WriteByte('-')
+	}
+	if format == AccessLogCombined {
+		b.WriteString(` "`)
+		b.WriteString(clfEscape(e.Referer))
+		b.WriteString(`" "`)
+		b.WriteString(clfEscape(e.UserAgent))
+		b.WriteByte('"')
+	}
+	return b.String()
+}
+
+// clfField returns s, or "-" if it is empty, with its spaces escaped.
+func clfField(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return strings.ReplaceAll(clfEscape(s), " ", "\\x20")
+}
+
+// clfEscape escapes the quotes, backslashes and control characters of s
+// so that a line cannot be forged.
+func clfEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch ch := s[i]; {
+		case ch == '"' || ch == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(ch)
+		case ch < 0x20 || ch == 0x7f:
+			b.WriteString(`\x`)
+			b.WriteString(strconv.FormatUint(uint64(ch)>>4, 16))
+			b.WriteString(strconv.FormatUint(uint64(ch)&0xf, 16))
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return b.String()
+}
+
+// accessLogWriter writes formatted entries to a writer.
+type accessLogWriter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	format AccessLogFormat
+}
+
+// NewAccessLogWriter returns a sink writing the entries to w in format,
+// one per line.
+func NewAccessLogWriter(w io.Writer, format AccessLogFormat) AccessLogSink {
+	return &accessLogWriter{w: w, format: format}
+}
+
+func (l *accessLogWriter) Log(entry *AccessLogEntry) {
+	line := entry.Format(l.format) + "\n"
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := io.WriteString(l.w, line); err != nil {
+		hlog.SystemLogger().Errorf("Failed to write access log: %s", err)
+	}
+}
+
+// newAccessLogEntry starts the entry of the request to the handler mounted
+// on mount, or returns nil if there is no access log nor metrics.
+func newAccessLogEntry(c *app.RequestContext, cfg *option, mount string) *AccessLogEntry {
+	if cfg.accessLog == nil && cfg.metrics == nil {
+		return nil
+	}
+	return &AccessLogEntry{
+		Time:       time.Now(),
+		RemoteAddr: c.ClientIP(),
+		Method:     string(c.Method()),
+		URI:        string(c.Request.RequestURI()),
+		Protocol:   c.Request.Header.GetProtocol(),
+		Mount:      mount,
+		Range:      string(c.Request.Header.Peek("Range")),
+		Referer:    string(c.Request.Header.Peek("Referer")),
+		UserAgent:  string(c.Request.Header.Peek("User-Agent")),
+	}
+}
+
+// logAccess completes entry with the response and sends it to the sink and
+// to the metrics.
+func logAccess(c *app.RequestContext, cfg *option, entry *AccessLogEntry) {
+	entry.Duration = time.Since(entry.Time)
+	entry.Status = c.Response.StatusCode()
+	entry.CacheHit = entry.Status == consts.StatusNotModified
+	entry.Encoding = string(c.Response.Header.Peek("Content-Encoding"))
+	switch {
+	case c.Response.SkipBody:
+	case !c.Response.IsBodyStream():
+		entry.Bytes = int64(len(c.Response.Body()))
+	case c.Response.Header.ContentLength() >= 0:
+		entry.Bytes = int64(c.Response.Header.ContentLength())
+	}
+	if cfg.principal != nil {
+		entry.Principal = cfg.principal(c)
+	}
+	if cfg.accessLog != nil {
+		cfg.accessLog.Log(entry)
+	}
+	if cfg.metrics != nil {
+		conditional := len(c.Request.Header.Peek(consts.HeaderIfModifiedSince)) > 0 ||
+			len(c.Request.Header.Peek("If-None-Match")) > 0
+		cfg.metrics.observe(c, entry, conditional)
+	}
+}
+
+// logNotFound logs a missing file at the level of WithNotFoundLogLevel.
+func logNotFound(cfg *option, format string, v ...interface{}) {
+	logger := hlog.SystemLogger()
+	switch cfg.notFoundLogLevel {
+	case hlog.LevelTrace:
+		logger.Tracef(format, v...)
+	case hlog.LevelDebug:
+		logger.Debugf(format, v...)
+	case hlog.LevelInfo:
+		logger.Infof(format, v...)
+	case hlog.LevelNotice:
+		logger.Noticef(format, v...)
+	case hlog.LevelWarn:
+		logger.Warnf(format, v...)
+	default:
+		logger.Errorf(format, v...)
+	}
+}
@@ -0,0 +1,119 @@
+package filesystem
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/common/test/assert"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+func TestAccessLogFormat(t *testing.T) {
+	t.Parallel()
+
+	entry := &AccessLogEntry{
+		Time:       time.Date(2000, 10, 10, 13, 55, 36, 0, time.FixedZone("", -7*3600)),
+		RemoteAddr: "127.0.0.1",
+		Method:     "GET",
+		URI:        `/a "quoted" path`,
+		Protocol:   "HTTP/1.1",
+		Mount:      "/static",
+		File:       "/a.txt",
+		Size:       2326,
+		Status:     200,
+		Bytes:      2326,
+		Duration:   1500 * time.Microsecond,
+		Principal:  "frank",
+		Referer:    "http://example.com/",
+		UserAgent:  "Mozilla/5.0\n",
+	}
+	assert.DeepEqual(t, `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /a \"quoted\" path HTTP/1.1" 200 2326`,
+		entry.Format(AccessLogCommon))
+	assert.DeepEqual(t, `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /a \"quoted\" path HTTP/1.1" 200 2326 "http://example.com/" "Mozilla/5.0\x0a"`,
+		entry.Format(AccessLogCombined))
+
+	var decoded map[string]interface{}
+	assert.Nil(t, json.Unmarshal([]byte(entry.Format(AccessLogJSON)), &decoded))
+	assert.DeepEqual(t, "/static", decoded["mount"])
+	assert.DeepEqual(t, "/a.txt", decoded["file"])
+	assert.DeepEqual(t, float64(2326), decoded["size"])
+	assert.DeepEqual(t, 1.5, decoded["duration_ms"])
+	assert.DeepEqual(t, float64(200), decoded["status"])
+
+	entry.Principal, entry.Bytes = "", 0
+	assert.DeepEqual(t, `127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET /a \"quoted\" path HTTP/1.1" 200 -`,
+		entry.Format(AccessLogCommon))
+}
+
+func TestAccessLog(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	entries := make(map[string]*AccessLogEntry)
+	h := server.New()
+	NewFSHandler(h, "/log", http.Dir("./examples/testdata/fs"),
+		WithAccessLog(AccessLogSinkFunc(func(entry *AccessLogEntry) {
+			mu.Lock()
+			entries[entry.Method+" "+entry.URI] = entry
+			mu.Unlock()
+		})),
+		WithPrincipal(func(c *app.RequestContext) string { return c.Request.Header.Get("X-User") }),
+		WithNotFoundLogLevel(hlog.LevelDebug),
+	)
+
+	w := ut.PerformRequest(h.Engine, consts.MethodGet, "/log/css/style.css", nil,
+		ut.Header{Key: "X-User", Value: "alice"}, ut.Header{Key: "Range", Value: "bytes=0-1"})
+	lastModified := w.Result().Header.Get(consts.HeaderLastModified)
+	ut.PerformRequest(h.Engine, consts.MethodGet, "/log/?v=1", nil,
+		ut.Header{Key: consts.HeaderIfModifiedSince, Value: lastModified})
+	ut.PerformRequest(h.Engine, consts.MethodHead, "/log/css/style.css", nil)
+	ut.PerformRequest(h.Engine, consts.MethodGet, "/log/missing.js", nil)
+
+	mu.Lock()
+	defer mu.Unlock()
+	get := entries["GET /log/css/style.css"]
+	assert.DeepEqual(t, "/css/style.css", get.File)
+	assert.DeepEqual(t, consts.StatusOK, get.Status)
+	assert.True(t, get.Bytes > 0)
+	assert.DeepEqual(t, "alice", get.Principal)
+	assert.DeepEqual(t, "bytes=0-1", get.Range)
+	assert.False(t, get.CacheHit)
+
+	notModified := entries["GET /log/?v=1"]
+	assert.DeepEqual(t, consts.StatusNotModified, notModified.Status)
+	assert.True(t, notModified.CacheHit)
+	assert.DeepEqual(t, "/index.html", notModified.File)
+
+	head := entries["HEAD /log/css/style.css"]
+	assert.DeepEqual(t, int64(0), head.Bytes)
+
+	missing := entries["GET /log/missing.js"]
+	assert.DeepEqual(t, consts.StatusNotFound, missing.Status)
+	assert.DeepEqual(t, "", missing.File)
+}
+
+func TestAccessLogWriter(t *testing.T) {
+	t.Parallel()
+
+	stat, err := os.Stat("./examples/testdata/fs/index.html")
+	assert.Nil(t, err)
+
+	var buf bytes.Buffer
+	h := server.New()
+	NewFSHandler(h, "", http.Dir("./examples/testdata/fs"), WithAccessLog(NewAccessLogWriter(&buf, AccessLogCommon)))
+	ut.PerformRequest(h.Engine, consts.MethodGet, "/index.html", nil)
+	line := buf.String()
+	assert.True(t, strings.Contains(line, `] "GET /index.html`))
+	assert.True(t, strings.HasSuffix(line, `" 200 `+strconv.FormatInt(stat.Size(), 10)+"\n"))
+}
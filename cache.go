@@ -0,0 +1,128 @@
+package filesystem
+
+import (
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// CachePolicy describes the caching headers sent with a file.
+type CachePolicy struct {
+	// Public allows shared caches to store the response.
+	Public bool
+	// Private restricts caching to the browser.
+	Private bool
+	// NoCache requires caches to revalidate the response before using it.
+	NoCache bool
+	// NoStore forbids caching the response at all.
+	NoStore bool
+	// Immutable tells the browser the response never changes while fresh.
+	Immutable bool
+	// MaxAge is the freshness lifetime, it is sent in seconds.
+	MaxAge time.Duration
+	// StaleWhileRevalidate lets caches serve a stale response for this long
+	// while revalidating it in the background.
+	StaleWhileRevalidate time.Duration
+	// Expires also sets the Expires header to MaxAge from now, for caches
+	// that do not understand Cache-Control.
+	Expires bool
+}
+
+// CacheRule applies a CachePolicy to the files matching Glob or Regexp.
+//
+// Glob is matched with path.Match against the file name, or against the
+// whole path in the root if the pattern contains a "/". Regexp is matched
+// against the whole path. A rule without Glob and Regexp matches any file.
+type CacheRule struct {
+	Glob   string
+	Regexp *regexp.Regexp
+	Policy CachePolicy
+}
+
+// String returns the Cache-Control value of the policy.
+func (p CachePolicy) String() string {
+	directives := make([]string, 0, 6)
+	if p.Public {
+		directives = append(directives, "public")
+	}
+	if p.Private {
+		directives = append(directives, "private")
+	}
+	if p.NoStore {
+		directives = append(directives, "no-store")
+	}
+	if p.NoCache {
+		directives = append(directives, "no-cache")
+	}
+	if p.MaxAge > 0 {
+		directives = append(directives, "max-age="+strconv.FormatInt(int64(p.MaxAge/time.Second), 10))
+	}
+	if p.StaleWhileRevalidate > 0 {
+		directives = append(directives, "stale-while-revalidate="+
+			strconv.FormatInt(int64(p.StaleWhileRevalidate/time.Second), 10))
+	}
+	if p.Immutable {
+		directives = append(directives, "immutable")
+	}
+	return strings.Join(directives, ", ")
+}
+
+func (r CacheRule) match(name string) bool {
+	if r.Glob != "" && !matchGlob(r.Glob, name) {
+		return false
+	}
+	if r.Regexp != nil && !r.Regexp.MatchString(name) {
+		return false
+	}
+	return true
+}
+
+// matchGlob matches glob against the file name of name, or against the
+// whole name if glob contains a "/".
+func matchGlob(glob, name string) bool {
+	if !strings.Contains(glob, "/") {
+		name = path.Base(name)
+	}
+	ok, _ := path.Match(glob, name)
+	return ok
+}
+
+// cachePolicy returns the policy of the first rule matching name, falling
+// back to the policy configured by WithMaxAge.
+func (o *option) cachePolicy(name string) (CachePolicy, bool) {
+	for _, rule := range o.cacheRules {
+		if rule.match(name) {
+			return rule.Policy, true
+		}
+	}
+	if o.maxAge > 0 {
+		return CachePolicy{Public: true, MaxAge: time.Duration(o.maxAge) * time.Second}, true
+	}
+	return CachePolicy{}, false
+}
+
+// setCacheHeaders sets the caching headers for the file served from name.
+// It is used alike for GET, HEAD and 304 responses. Executed templates are
+// never stored by shared caches, whatever the policy, their content
+// depending on the request.
+func setCacheHeaders(c *app.RequestContext, cfg *option, name string) {
+	if cfg.templates != nil && cfg.templates.executes(name) {
+		c.Response.Header.Set("Cache-Control", "private, no-cache")
+		return
+	}
+	policy, ok := cfg.cachePolicy(name)
+	if !ok {
+		return
+	}
+	if value := policy.String(); value != "" {
+		c.Response.Header.Set("Cache-Control", value)
+	}
+	if policy.Expires {
+		c.Response.Header.Set("Expires", time.Now().Add(policy.MaxAge).UTC().Format(http.TimeFormat))
+	}
+}
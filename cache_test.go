@@ -0,0 +1,115 @@
+package filesystem
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/test/assert"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+func TestCachePolicyString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		policy CachePolicy
+		want   string
+	}{
+		{CachePolicy{}, ""},
+		{CachePolicy{NoCache: true}, "no-cache"},
+		{CachePolicy{Private: true, NoStore: true}, "private, no-store"},
+		{
+			CachePolicy{Public: true, MaxAge: 365 * 24 * time.Hour, Immutable: true},
+			"public, max-age=31536000, immutable",
+		},
+		{
+			CachePolicy{Public: true, MaxAge: time.Minute, StaleWhileRevalidate: time.Hour},
+			"public, max-age=60, stale-while-revalidate=3600",
+		},
+	}
+	for _, tt := range tests {
+		assert.DeepEqual(t, tt.want, tt.policy.String())
+	}
+}
+
+func TestCacheRules(t *testing.T) {
+	t.Parallel()
+
+	h := server.New()
+	NewFSHandler(h, "/cache", http.Dir("./examples/testdata/fs"),
+		WithMaxAge(60),
+		WithCacheRules(
+			CacheRule{Glob: "*.html", Policy: CachePolicy{NoCache: true}},
+			CacheRule{
+				Regexp: regexp.MustCompile(`^/img/.*\.png$`),
+				Policy: CachePolicy{Public: true, MaxAge: 365 * 24 * time.Hour, Immutable: true, Expires: true},
+			},
+		),
+	)
+	future := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+
+	tests := []struct {
+		name         string
+		method       string
+		url          string
+		headers      []ut.Header
+		statusCode   int
+		cacheControl string
+		expires      bool
+	}{
+		{
+			name:         "Should apply a glob rule to the index",
+			method:       consts.MethodGet,
+			url:          "/cache/",
+			statusCode:   200,
+			cacheControl: "no-cache",
+		},
+		{
+			name:         "Should apply a regexp rule",
+			method:       consts.MethodGet,
+			url:          "/cache/img/fiber.png",
+			statusCode:   200,
+			cacheControl: "public, max-age=31536000, immutable",
+			expires:      true,
+		},
+		{
+			name:         "Should apply rules to HEAD",
+			method:       consts.MethodHead,
+			url:          "/cache/img/fiber.png",
+			statusCode:   200,
+			cacheControl: "public, max-age=31536000, immutable",
+			expires:      true,
+		},
+		{
+			name:         "Should apply rules to 304",
+			method:       consts.MethodGet,
+			url:          "/cache/img/fiber.png",
+			headers:      []ut.Header{{Key: "If-Modified-Since", Value: future}},
+			statusCode:   304,
+			cacheControl: "public, max-age=31536000, immutable",
+			expires:      true,
+		},
+		{
+			name:         "Should fall back to max age",
+			method:       consts.MethodHead,
+			url:          "/cache/css/style.css",
+			statusCode:   200,
+			cacheControl: "public, max-age=60",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := ut.PerformRequest(h.Engine, tt.method, tt.url, nil, tt.headers...)
+			response := w.Result()
+			assert.DeepEqual(t, tt.statusCode, response.StatusCode())
+			assert.DeepEqual(t, tt.cacheControl, response.Header.Get("Cache-Control"))
+			assert.DeepEqual(t, tt.expires, response.Header.Get("Expires") != "")
+		})
+	}
+}
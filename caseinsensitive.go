@@ -0,0 +1,109 @@
+package filesystem
+
+import (
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CaseInsensitiveMode is the case-insensitive lookup mode set by
+// WithCaseInsensitive.
+type CaseInsensitiveMode int
+
+const (
+	// CaseInsensitiveOff only serves files whose path matches exactly.
+	CaseInsensitiveOff CaseInsensitiveMode = iota
+	// CaseInsensitiveServe serves the file matching case-insensitively.
+	CaseInsensitiveServe
+	// CaseInsensitiveRedirect redirects to the path of the file matching
+	// case-insensitively with a 301.
+	CaseInsensitiveRedirect
+)
+
+// caseIndex caches the directory listings used to resolve paths
+// case-insensitively.
+type caseIndex struct {
+	mu   sync.Mutex
+	dirs map[string]*dirNames
+}
+
+// dirNames are the names in a directory, as of its modification time.
+type dirNames struct {
+	modTime time.Time
+	exact   map[string]bool
+	// folded maps lower cased names to the name in the directory. When
+	// several names only differ by case, the smallest one wins.
+	folded map[string]string
+}
+
+func newCaseIndex() *caseIndex {
+	return &caseIndex{dirs: make(map[string]*dirNames)}
+}
+
+// lookup resolves name in the root segment by segment, matching the
+// segments that do not exist case-insensitively. It returns false if a
+// segment matches no name at all.
+func (ci *caseIndex) lookup(o *option, name string) (string, bool) {
+	resolvedPath := "/"
+	for _, segment := range strings.Split(strings.Trim(name, "/"), "/") {
+		if segment == "" {
+			continue
+		}
+		names, ok := ci.names(o, resolvedPath)
+		if !ok {
+			return "", false
+		}
+		if !names.exact[segment] {
+			if segment, ok = names.folded[strings.ToLower(segment)]; !ok {
+				return "", false
+			}
+		}
+		resolvedPath = path.Join(resolvedPath, segment)
+	}
+	return resolvedPath, true
+}
+
+// names returns the names in dir, listing it again if it was modified
+// since it was cached.
+func (ci *caseIndex) names(o *option, dir string) (*dirNames, bool) {
+	f, err := o.root.Open(dir)
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil || !stat.IsDir() {
+		return nil, false
+	}
+
+	ci.mu.Lock()
+	cached, ok := ci.dirs[dir]
+	ci.mu.Unlock()
+	if ok && cached.modTime.Equal(stat.ModTime()) {
+		return cached, true
+	}
+
+	infos, err := f.Readdir(-1)
+	if err != nil {
+		return nil, false
+	}
+	names := &dirNames{
+		modTime: stat.ModTime(),
+		exact:   make(map[string]bool, len(infos)),
+		folded:  make(map[string]string, len(infos)),
+	}
+	for _, info := range infos {
+		name := info.Name()
+		names.exact[name] = true
+		key := strings.ToLower(name)
+		if current, ok := names.folded[key]; !ok || name < current {
+			names.folded[key] = name
+		}
+	}
+
+	ci.mu.Lock()
+	ci.dirs[dir] = names
+	ci.mu.Unlock()
+	return names, true
+}
@@ -0,0 +1,87 @@
+package filesystem
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/test/assert"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+func TestCaseInsensitive(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	files := map[string]string{
+		"images/logo.png":     "logo",
+		"Docs/index.html":     "docs",
+		"collide/readme.txt":  "lower",
+		"collide/README.txt":  "upper",
+		"My Files/Report.pdf": "report",
+	}
+	for name, content := range files {
+		name = filepath.Join(root, filepath.FromSlash(name))
+		assert.Nil(t, os.MkdirAll(filepath.Dir(name), 0o755))
+		assert.Nil(t, os.WriteFile(name, []byte(content), 0o644))
+	}
+
+	h := server.New()
+	NewFSHandler(h, "/serve", http.Dir(root), WithCaseInsensitive(CaseInsensitiveServe))
+	NewFSHandler(h, "/redirect", http.Dir(root), WithCaseInsensitive(CaseInsensitiveRedirect))
+	NewFSHandler(h, "/off", http.Dir(root))
+
+	tests := []struct {
+		name       string
+		url        string
+		statusCode int
+		location   string
+		body       string
+	}{
+		{name: "Should serve an exact match", url: "/serve/images/logo.png", statusCode: 200, body: "logo"},
+		{name: "Should serve a case-insensitive match", url: "/serve/Images/Logo.PNG", statusCode: 200, body: "logo"},
+		{name: "Should serve the index of a folded directory", url: "/serve/docs/", statusCode: 200, body: "docs"},
+		{name: "Should prefer an exact match", url: "/serve/collide/readme.txt", statusCode: 200, body: "lower"},
+		{name: "Should pick the smallest name", url: "/serve/collide/ReadMe.TXT", statusCode: 200, body: "upper"},
+		{name: "Should miss unknown names", url: "/serve/images/missing.png", statusCode: 404},
+		{name: "Should redirect to the canonical path", url: "/redirect/IMAGES/logo.png", statusCode: 301, location: "/redirect/images/logo.png"},
+		{name: "Should keep the trailing slash", url: "/redirect/docs/", statusCode: 301, location: "/redirect/Docs/"},
+		{name: "Should escape the canonical path", url: "/redirect/my%20files/report.PDF", statusCode: 301, location: "/redirect/My%20Files/Report.pdf"},
+		{name: "Should be case-sensitive by default", url: "/off/Images/Logo.PNG", statusCode: 404},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := ut.PerformRequest(h.Engine, consts.MethodGet, tt.url, nil)
+			response := w.Result()
+			assert.DeepEqual(t, tt.statusCode, response.StatusCode())
+			if tt.location != "" {
+				assert.DeepEqual(t, tt.location, string(response.Header.Peek("Location")))
+			}
+			if tt.body != "" {
+				assert.DeepEqual(t, tt.body, string(response.Body()))
+			}
+		})
+	}
+}
+
+func TestCaseIndexRefreshesChangedDirectories(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	cfg := newOption(http.Dir(root), []Option{WithCaseInsensitive(CaseInsensitiveServe)})
+
+	_, ok := cfg.caseIndex.lookup(cfg, "/Late.txt")
+	assert.False(t, ok)
+
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "late.txt"), []byte("late"), 0o644))
+	assert.Nil(t, os.Chtimes(root, time.Now(), time.Now().Add(time.Minute)))
+	name, ok := cfg.caseIndex.lookup(cfg, "/Late.txt")
+	assert.True(t, ok)
+	assert.DeepEqual(t, "/late.txt", name)
+}
@@ -0,0 +1,75 @@
+package filesystem
+
+import (
+	"strings"
+)
+
+// TrailingSlash is the trailing slash policy set by WithTrailingSlash.
+type TrailingSlash int
+
+const (
+	// TrailingSlashPreserve serves paths alike with or without a trailing
+	// slash, without redirecting.
+	TrailingSlashPreserve TrailingSlash = iota
+	// TrailingSlashAlways redirects directories and clean URLs to the path
+	// ending with a slash. Other files are redirected to the path without.
+	TrailingSlashAlways
+	// TrailingSlashNever redirects paths ending with a slash to the path
+	// without it.
+	TrailingSlashNever
+)
+
+// cleanURLCandidate is the candidate added to the default try files chain
+// in clean URL mode.
+const cleanURLCandidate = "$uri.html"
+
+// canonicalPath returns the path a request for urlPath that resolved to
+// res is redirected to, so that it follows the case-insensitive redirects,
+// clean URL and trailing slash policies. It returns false if urlPath is
+// already canonical.
+func (o *option) canonicalPath(urlPath string, res resolved) (string, bool) {
+	if !strings.HasPrefix(res.via, "$uri") {
+		// Fallbacks do not tell anything about the requested path.
+		return "", false
+	}
+
+	canonical := urlPath
+	if res.folded != "" && o.caseInsensitive == CaseInsensitiveRedirect {
+		canonical = strings.TrimPrefix(res.folded, o.pathPrefix)
+		if strings.HasSuffix(urlPath, "/") && canonical != "/" {
+			canonical += "/"
+		}
+	}
+	dir := res.dir
+	clean := o.cleanURLs && res.via == cleanURLCandidate
+	if o.cleanURLs && !res.dir && res.via == "$uri" {
+		trimmed := trimRight(canonical, '/')
+		switch {
+		case strings.HasSuffix(trimmed, o.index):
+			canonical = strings.TrimSuffix(trimmed, o.index[1:])
+			dir = true
+		case strings.HasSuffix(trimmed, ".html"):
+			canonical = strings.TrimSuffix(trimmed, ".html")
+			clean = true
+		}
+	}
+
+	switch o.trailingSlash {
+	case TrailingSlashAlways:
+		if dir || clean {
+			if !strings.HasSuffix(canonical, "/") {
+				canonical += "/"
+			}
+		} else if len(canonical) > 1 {
+			canonical = trimRight(canonical, '/')
+		}
+	case TrailingSlashNever:
+		if len(canonical) > 1 {
+			canonical = trimRight(canonical, '/')
+		}
+	}
+	if canonical == "" {
+		canonical = "/"
+	}
+	return canonical, canonical != urlPath
+}
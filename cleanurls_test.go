@@ -0,0 +1,77 @@
+package filesystem
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/test/assert"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+func TestCleanURLs(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	files := map[string]string{
+		"index.html":      "home",
+		"about.html":      "about",
+		"docs/index.html": "docs",
+		"style.css":       "css",
+	}
+	for name, content := range files {
+		name = filepath.Join(root, filepath.FromSlash(name))
+		assert.Nil(t, os.MkdirAll(filepath.Dir(name), 0o755))
+		assert.Nil(t, os.WriteFile(name, []byte(content), 0o644))
+	}
+
+	h := server.New()
+	NewFSHandler(h, "/clean", http.Dir(root), WithCleanURLs(true))
+	NewFSHandler(h, "/always", http.Dir(root), WithCleanURLs(true), WithTrailingSlash(TrailingSlashAlways))
+	NewFSHandler(h, "/never", http.Dir(root), WithCleanURLs(true), WithTrailingSlash(TrailingSlashNever))
+	NewFSHandler(h, "/plain", http.Dir(root), WithTrailingSlash(TrailingSlashAlways))
+
+	tests := []struct {
+		name       string
+		url        string
+		statusCode int
+		location   string
+		body       string
+	}{
+		{name: "Should serve a clean URL", url: "/clean/about", statusCode: 200, body: "about"},
+		{name: "Should redirect the html form", url: "/clean/about.html?a=1", statusCode: 301, location: "/clean/about?a=1"},
+		{name: "Should redirect an index file", url: "/clean/docs/index.html", statusCode: 301, location: "/clean/docs/"},
+		{name: "Should redirect the root index", url: "/clean/index.html", statusCode: 301, location: "/clean/"},
+		{name: "Should preserve trailing slashes", url: "/clean/docs", statusCode: 200, body: "docs"},
+		{name: "Should serve other files", url: "/clean/style.css", statusCode: 200, body: "css"},
+		{name: "Should add a slash to directories", url: "/always/docs", statusCode: 301, location: "/always/docs/"},
+		{name: "Should add a slash to clean URLs", url: "/always/about", statusCode: 301, location: "/always/about/"},
+		{name: "Should serve a clean URL with a slash", url: "/always/about/", statusCode: 200, body: "about"},
+		{name: "Should redirect the html form with a slash", url: "/always/about.html", statusCode: 301, location: "/always/about/"},
+		{name: "Should remove the slash of files", url: "/always/style.css/", statusCode: 301, location: "/always/style.css"},
+		{name: "Should remove the slash of directories", url: "/never/docs/", statusCode: 301, location: "/never/docs"},
+		{name: "Should serve a directory without slash", url: "/never/docs", statusCode: 200, body: "docs"},
+		{name: "Should redirect an index file without slash", url: "/never/docs/index.html", statusCode: 301, location: "/never/docs"},
+		{name: "Should keep the root slash", url: "/never/", statusCode: 200, body: "home"},
+		{name: "Should not serve clean URLs when disabled", url: "/plain/about", statusCode: 404},
+		{name: "Should not redirect the html form when disabled", url: "/plain/about.html", statusCode: 200, body: "about"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := ut.PerformRequest(h.Engine, consts.MethodGet, tt.url, nil)
+			response := w.Result()
+			assert.DeepEqual(t, tt.statusCode, response.StatusCode())
+			if tt.location != "" {
+				assert.DeepEqual(t, tt.location, string(response.Header.Peek("Location")))
+			}
+			if tt.body != "" {
+				assert.DeepEqual(t, tt.body, string(response.Body()))
+			}
+		})
+	}
+}
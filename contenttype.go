@@ -0,0 +1,81 @@
+package filesystem
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// sniffLen is the number of bytes http.DetectContentType looks at.
+const sniffLen = 512
+
+// contentType returns the content type of the file named name: the type
+// of its extension, from WithContentTypes and then from the MIME registry,
+// or else the type sniffed from its content with WithContentSniffing. The
+// charset of WithCharset is added to text types.
+func (o *option) contentType(file http.File, name string) string {
+	ext := getFileExtension(name)
+	ct := ""
+	if ext != "" {
+		ct = o.contentTypes[normalizeExt(ext)]
+		if ct == "" {
+			ct, _ = o.mimeTypes().Lookup(ext)
+		}
+	}
+	if ct == "" && o.sniff {
+		ct = sniffContentType(file)
+	}
+	if ct == "" && ext != "" {
+		ct = MIMEOctetStream
+	}
+	if o.charset != "" && isTextType(ct) {
+		if _, params, err := mime.ParseMediaType(ct); err == nil && params["charset"] == "" {
+			ct += "; charset=" + o.charset
+		}
+	}
+	return ct
+}
+
+// sniffContentType detects the content type of file from its first bytes
+// and seeks back to its start. It returns an empty type if file cannot be
+// read.
+func sniffContentType(file http.File) string {
+	buf := make([]byte, sniffLen)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return ""
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return ""
+	}
+	return http.DetectContentType(buf[:n])
+}
+
+// isTextType reports whether the content type ct is text a charset
+// applies to.
+func isTextType(ct string) bool {
+	mediaType, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(mediaType, "text/"):
+		return true
+	case strings.HasSuffix(mediaType, "+xml"), strings.HasSuffix(mediaType, "+json"):
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/xml", "application/javascript":
+		return true
+	}
+	return false
+}
+
+// mimeTypes returns the registry of WithMIMERegistry, or the default one.
+func (o *option) mimeTypes() *MIMERegistry {
+	if o.mimeRegistry != nil {
+		return o.mimeRegistry
+	}
+	return DefaultMIMERegistry
+}
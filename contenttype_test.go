@@ -0,0 +1,65 @@
+package filesystem
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/test/assert"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+func TestContentType(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	files := map[string]string{
+		"page.html":    "<p>page</p>",
+		"LICENSE":      "plain text license",
+		"image.dat":    "\x89PNG\r\n\x1a\n0000",
+		"app.manifest": `{"name": "app"}`,
+		"logo.png":     "not really a png",
+	}
+	for name, content := range files {
+		assert.Nil(t, os.WriteFile(filepath.Join(root, name), []byte(content), 0o644))
+	}
+
+	h := server.New()
+	NewFSHandler(h, "/plain", http.Dir(root))
+	NewFSHandler(h, "/detect", http.Dir(root),
+		WithContentTypes(map[string]string{".Manifest": "application/manifest+json"}),
+		WithContentSniffing(true),
+		WithCharset("utf-8"),
+		WithNoSniff(true),
+	)
+
+	tests := []struct {
+		name        string
+		url         string
+		contentType string
+		noSniff     string
+	}{
+		{name: "Should use the extension", url: "/plain/page.html", contentType: "text/html"},
+		{name: "Should default unknown extensions", url: "/plain/image.dat", contentType: MIMEOctetStream},
+		{name: "Should add the charset to text types", url: "/detect/page.html", contentType: "text/html; charset=utf-8", noSniff: "nosniff"},
+		{name: "Should prefer the extension to sniffing", url: "/detect/logo.png", contentType: "image/png", noSniff: "nosniff"},
+		{name: "Should use the user types", url: "/detect/app.manifest", contentType: "application/manifest+json; charset=utf-8", noSniff: "nosniff"},
+		{name: "Should sniff files without extension", url: "/detect/LICENSE", contentType: "text/plain; charset=utf-8", noSniff: "nosniff"},
+		{name: "Should sniff unknown extensions", url: "/detect/image.dat", contentType: "image/png", noSniff: "nosniff"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := ut.PerformRequest(h.Engine, consts.MethodGet, tt.url, nil)
+			response := w.Result()
+			assert.DeepEqual(t, consts.StatusOK, response.StatusCode())
+			assert.DeepEqual(t, tt.contentType, string(response.Header.ContentType()))
+			assert.DeepEqual(t, tt.noSniff, string(response.Header.Peek("X-Content-Type-Options")))
+			assert.DeepEqual(t, files[filepath.Base(tt.url)], string(response.Body()))
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package filesystem
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// CORS is the cross-origin resource sharing policy set by WithCORS.
+type CORS struct {
+	// AllowOrigins are the origins allowed to load the files, such as
+	// "https://example.com", or "*" for any origin.
+	AllowOrigins []string
+	// AllowHeaders are the request headers preflight requests may ask for.
+	AllowHeaders []string
+	// AllowCredentials allows requests with cookies or authorization. The
+	// origin of the request is then sent back in place of "*".
+	AllowCredentials bool
+	// MaxAge is how long the result of a preflight request may be cached,
+	// zero to leave it to the browser.
+	MaxAge time.Duration
+}
+
+// allowOrigin returns the Access-Control-Allow-Origin of a request from
+// origin, or false if origin is not allowed.
+func (p *CORS) allowOrigin(origin string) (string, bool) {
+	if origin == "" {
+		return "", false
+	}
+	for _, allowed := range p.AllowOrigins {
+		if allowed == "*" {
+			if p.AllowCredentials {
+				return origin, true
+			}
+			return "*", true
+		}
+		if strings.EqualFold(allowed, origin) {
+			return origin, true
+		}
+	}
+	return "", false
+}
+
+// setCORSHeaders sets the CORS headers of a response to an allowed origin.
+func setCORSHeaders(c *app.RequestContext, p *CORS) {
+	allowed, ok := p.allowOrigin(string(c.Request.Header.Peek("Origin")))
+	if allowed != "*" {
+		c.Response.Header.Add("Vary", "Origin")
+	}
+	if !ok {
+		return
+	}
+	c.Response.Header.Set("Access-Control-Allow-Origin", allowed)
+	if p.AllowCredentials {
+		c.Response.Header.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// setPreflightHeaders answers a preflight request for a file.
+func setPreflightHeaders(c *app.RequestContext, p *CORS) {
+	setCORSHeaders(c, p)
+	if len(c.Response.Header.Peek("Access-Control-Allow-Origin")) == 0 {
+		return
+	}
+	c.Response.Header.Set("Access-Control-Allow-Methods", allowedMethods)
+	if len(p.AllowHeaders) > 0 {
+		c.Response.Header.Set("Access-Control-Allow-Headers", strings.Join(p.AllowHeaders, ", "))
+	}
+	if p.MaxAge > 0 {
+		c.Response.Header.Set("Access-Control-Max-Age", strconv.FormatInt(int64(p.MaxAge/time.Second), 10))
+	}
+}
@@ -0,0 +1,80 @@
+package filesystem
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/test/assert"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+func TestCORS(t *testing.T) {
+	t.Parallel()
+
+	h := server.New()
+	NewFSHandler(h, "/fonts", http.Dir("./examples/testdata/fs"),
+		WithCORS(CORS{
+			AllowOrigins:     []string{"https://app.example.com"},
+			AllowHeaders:     []string{"Range"},
+			AllowCredentials: true,
+			MaxAge:           10 * time.Minute,
+		}),
+		WithPreHandler(func(_ context.Context, c *app.RequestContext) (func(), bool) {
+			return nil, len(c.Request.Header.Peek("Authorization")) > 0
+		}),
+	)
+	NewFSHandler(h, "/public", http.Dir("./examples/testdata/fs"), WithCORS(CORS{AllowOrigins: []string{"*"}}))
+
+	tests := []struct {
+		name        string
+		method      string
+		url         string
+		headers     []ut.Header
+		statusCode  int
+		origin      string
+		credentials string
+		methods     string
+		maxAge      string
+		vary        string
+	}{
+		{
+			name: "Should answer a preflight without credentials", method: consts.MethodOptions, url: "/fonts/css/style.css",
+			headers:    []ut.Header{{Key: "Origin", Value: "https://app.example.com"}, {Key: "Access-Control-Request-Method", Value: "GET"}},
+			statusCode: 204, origin: "https://app.example.com", credentials: "true", methods: allowedMethods, maxAge: "600", vary: "Origin",
+		},
+		{
+			name: "Should not allow other origins", method: consts.MethodOptions, url: "/fonts/css/style.css",
+			headers:    []ut.Header{{Key: "Origin", Value: "https://evil.example.com"}, {Key: "Access-Control-Request-Method", Value: "GET"}},
+			statusCode: 204, vary: "Origin",
+		},
+		{
+			name: "Should allow the origin of a request", method: consts.MethodGet, url: "/fonts/css/style.css",
+			headers:    []ut.Header{{Key: "Origin", Value: "https://app.example.com"}, {Key: "Authorization", Value: "token"}},
+			statusCode: 200, origin: "https://app.example.com", credentials: "true", vary: "Origin",
+		},
+		{
+			name: "Should allow any origin", method: consts.MethodGet, url: "/public/css/style.css",
+			headers:    []ut.Header{{Key: "Origin", Value: "https://other.org"}},
+			statusCode: 200, origin: "*",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := ut.PerformRequest(h.Engine, tt.method, tt.url, nil, tt.headers...)
+			response := w.Result()
+			assert.DeepEqual(t, tt.statusCode, response.StatusCode())
+			assert.DeepEqual(t, tt.origin, string(response.Header.Peek("Access-Control-Allow-Origin")))
+			assert.DeepEqual(t, tt.credentials, string(response.Header.Peek("Access-Control-Allow-Credentials")))
+			assert.DeepEqual(t, tt.methods, string(response.Header.Peek("Access-Control-Allow-Methods")))
+			assert.DeepEqual(t, tt.maxAge, string(response.Header.Peek("Access-Control-Max-Age")))
+			assert.DeepEqual(t, tt.vary, string(response.Header.Peek("Vary")))
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package filesystem
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"mime"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// signatureQuery is the query parameter holding the signature of a
+// download filename, see SignFilename.
+const signatureQuery = "sig"
+
+// Disposition is the Content-Disposition policy set by WithDisposition.
+type Disposition struct {
+	// Query is the query parameter serving a file as an attachment when
+	// true, such as "download" for "?download=1". Empty disables it.
+	Query string
+	// Attachments are the globs of the files always served as
+	// attachments, matched like the Glob of CacheRule.
+	Attachments []string
+	// FilenameQuery is the query parameter overriding the filename the
+	// browser saves the file as. Empty disables it. Filenames with a path
+	// separator or a control character are ignored.
+	FilenameQuery string
+	// FilenameKey, if set, only accepts the filenames of FilenameQuery
+	// signed with it by SignFilename, the signature being sent in the
+	// "sig" query parameter.
+	FilenameKey []byte
+	// UploadPrefixes are the paths of user uploaded files, relative to the
+	// handler. Types a browser would run, such as HTML or SVG, are always
+	// served as attachments under them. They are matched against the file
+	// served, after rewrites and case-insensitive lookups.
+	UploadPrefixes []string
+}
+
+// activeTypes are the media types served as attachments from the upload
+// prefixes, since they can run scripts in the origin of the site.
+var activeTypes = map[string]bool{
+	"text/html":             true,
+	"application/xhtml+xml": true,
+	"image/svg+xml":         true,
+	"application/xml":       true,
+	"text/xml":              true,
+	"text/javascript":       true,
+}
+
+// SignFilename returns the signature of filename as the download filename
+// of the file at urlPath, the request path relative to the handler, for
+// Disposition.FilenameKey.
+func SignFilename(key []byte, urlPath, filename string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(urlPath))
+	mac.Write([]byte{0})
+	mac.Write([]byte(filename))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// setDisposition sets the Content-Disposition of a response serving the
+// file named name, of content type contentType, for urlPath.
+func setDisposition(c *app.RequestContext, cfg *option, urlPath, name, contentType string) {
+	d := cfg.disposition
+	attachment := false
+	if d.Query != "" {
+		attachment, _ = strconv.ParseBool(c.Query(d.Query))
+	}
+	for _, glob := range d.Attachments {
+		attachment = attachment || matchGlob(glob, name)
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if activeTypes[mediaType] {
+		attachment = attachment || isUpload(cfg, name)
+	}
+
+	filename := ""
+	if d.FilenameQuery != "" {
+		if requested := c.Query(d.FilenameQuery); validFilename(requested) {
+			if d.FilenameKey == nil || hmac.Equal(
+				[]byte(c.Query(signatureQuery)), []byte(SignFilename(d.FilenameKey, urlPath, requested)),
+			) {
+				filename = requested
+			}
+		}
+	}
+	if !attachment && filename == "" {
+		return
+	}
+	if filename == "" {
+		filename = name[strings.LastIndexByte(name, '/')+1:]
+	}
+	disposition := "inline"
+	if attachment {
+		disposition = "attachment"
+	}
+	c.Response.Header.Set("Content-Disposition", formatDisposition(disposition, filename))
+}
+
+// isUpload reports whether the file named name in the root is under one
+// of the upload prefixes, compared case-insensitively if the handler looks
+// files up so.
+func isUpload(cfg *option, name string) bool {
+	served := strings.TrimPrefix(name, cfg.pathPrefix)
+	for _, prefix := range cfg.disposition.UploadPrefixes {
+		prefix = "/" + strings.Trim(prefix, "/")
+		if len(served) < len(prefix) {
+			continue
+		}
+		head := served[:len(prefix)]
+		if cfg.caseInsensitive != CaseInsensitiveOff {
+			if !strings.EqualFold(head, prefix) {
+				continue
+			}
+		} else if head != prefix {
+			continue
+		}
+		if len(served) == len(prefix) || prefix == "/" || served[len(prefix)] == '/' {
+			return true
+		}
+	}
+	return false
+}
+
+// validFilename reports whether name can be offered as a download filename.
+func validFilename(name string) bool {
+	if name == "" || len(name) > 255 || !utf8.ValidString(name) || name == "." || name == ".." {
+		return false
+	}
+	for _, r := range name {
+		if r == '/' || r == '\\' || unicode.IsControl(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// formatDisposition formats a Content-Disposition with filename as in RFC
+// 6266: an ASCII filename parameter, followed by the RFC 5987 encoded
+// filename* parameter if filename is not ASCII.
+func formatDisposition(disposition, filename string) string {
+	var fallback strings.Builder
+	ascii := true
+	for _, r := range filename {
+		switch {
+		case r >= utf8.RuneSelf:
+			ascii = false
+			fallback.WriteByte('_')
+		case r == '"' || r == '\\':
+			fallback.WriteByte('\\')
+			fallback.WriteRune(r)
+		default:
+			fallback.WriteRune(r)
+		}
+	}
+	value := disposition + `; filename="` + fallback.String() + `"`
+	if !ascii {
+		value += "; filename*=UTF-8''" + encodeRFC5987(filename)
+	}
+	return value
+}
+
+// encodeRFC5987 percent-encodes the bytes of s that are not attr-char.
+func encodeRFC5987(s string) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if ('a' <= ch && ch <= 'z') || ('A' <= ch && ch <= 'Z') || ('0' <= ch && ch <= '9') ||
+			strings.IndexByte("!#$&+-.^_`|~", ch) >= 0 {
+			b.WriteByte(ch)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hexDigits[ch>>4])
+		b.WriteByte(hexDigits[ch&0x0f])
+	}
+	return b.String()
+}
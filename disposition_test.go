@@ -0,0 +1,122 @@
+package filesystem
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/test/assert"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+func TestDisposition(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	files := []string{"report.pdf", "archive.zip", "page.html", "uploads/x.svg", "uploads/photo.png", "uploads-old/y.svg", "Résumé.txt"}
+	for _, name := range files {
+		name = filepath.Join(root, filepath.FromSlash(name))
+		assert.Nil(t, os.MkdirAll(filepath.Dir(name), 0o755))
+		assert.Nil(t, os.WriteFile(name, []byte("content"), 0o644))
+	}
+
+	key := []byte("secret")
+	h := server.New()
+	NewFSHandler(h, "/files", http.Dir(root), WithDisposition(Disposition{
+		Query:          "download",
+		Attachments:    []string{"*.zip"},
+		FilenameQuery:  "filename",
+		UploadPrefixes: []string{"/uploads/"},
+	}))
+	NewFSHandler(h, "/ci", http.Dir(root),
+		WithCaseInsensitive(CaseInsensitiveServe),
+		WithRedirects(RedirectRule{From: "/avatar", To: "/uploads/x.svg", Status: 200}),
+		WithDisposition(Disposition{UploadPrefixes: []string{"/uploads"}}),
+	)
+	NewFSHandler(h, "/signed", http.Dir(root), WithDisposition(Disposition{FilenameQuery: "filename", FilenameKey: key}))
+
+	tests := []struct {
+		name        string
+		url         string
+		disposition string
+	}{
+		{name: "Should serve inline by default", url: "/files/report.pdf"},
+		{name: "Should download on demand", url: "/files/report.pdf?download=1", disposition: `attachment; filename="report.pdf"`},
+		{name: "Should ignore a false download", url: "/files/report.pdf?download=0"},
+		{name: "Should download by glob", url: "/files/archive.zip", disposition: `attachment; filename="archive.zip"`},
+		{name: "Should force active types in uploads", url: "/files/uploads/x.svg", disposition: `attachment; filename="x.svg"`},
+		{name: "Should serve other types in uploads inline", url: "/files/uploads/photo.png"},
+		{name: "Should serve active types elsewhere inline", url: "/files/page.html"},
+		{name: "Should force active types in folded uploads", url: "/ci/Uploads/X.svg", disposition: `attachment; filename="x.svg"`},
+		{name: "Should force active types in rewritten uploads", url: "/ci/avatar", disposition: `attachment; filename="x.svg"`},
+		{name: "Should not match a longer directory name", url: "/ci/uploads-old/y.svg"},
+		{
+			name: "Should encode non-ASCII filenames", url: "/files/" + url.PathEscape("Résumé.txt") + "?download=true",
+			disposition: `attachment; filename="R_sum_.txt"; filename*=UTF-8''R%C3%A9sum%C3%A9.txt`,
+		},
+		{
+			name: "Should override the filename", url: "/files/report.pdf?download=1&filename=" + url.QueryEscape(`Q3 "final".pdf`),
+			disposition: `attachment; filename="Q3 \"final\".pdf"`,
+		},
+		{name: "Should keep the filename inline", url: "/files/report.pdf?filename=q3.pdf", disposition: `inline; filename="q3.pdf"`},
+		{name: "Should reject filenames with a path", url: "/files/report.pdf?download=1&filename=..%2Fevil.sh", disposition: `attachment; filename="report.pdf"`},
+		{name: "Should reject unsigned filenames", url: "/signed/report.pdf?filename=q3.pdf"},
+		{
+			name: "Should accept signed filenames", url: "/signed/report.pdf?filename=q3.pdf&sig=" + SignFilename(key, "/report.pdf", "q3.pdf"),
+			disposition: `inline; filename="q3.pdf"`,
+		},
+		{name: "Should reject bad signatures", url: "/signed/report.pdf?filename=q4.pdf&sig=" + SignFilename(key, "/report.pdf", "q3.pdf")},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := ut.PerformRequest(h.Engine, consts.MethodGet, tt.url, nil)
+			response := w.Result()
+			assert.DeepEqual(t, consts.StatusOK, response.StatusCode())
+			assert.DeepEqual(t, tt.disposition, string(response.Header.Peek("Content-Disposition")))
+		})
+	}
+}
+
+func TestDispositionRenderedPages(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for _, name := range []string{"index.html", "uploads/evil/index.html", "uploads/page.tmpl.html"} {
+		name = filepath.Join(root, filepath.FromSlash(name))
+		assert.Nil(t, os.MkdirAll(filepath.Dir(name), 0o755))
+		assert.Nil(t, os.WriteFile(name, []byte("<head></head><script>alert(1)</script>"), 0o644))
+	}
+
+	h := server.New()
+	NewFSHandler(h, "/files", http.Dir(root),
+		WithConfigInjection(ConfigInjection{Config: 1}),
+		WithTemplates(Templates{Patterns: []string{"*.tmpl.html"}}),
+		WithDisposition(Disposition{UploadPrefixes: []string{"/uploads"}}),
+	)
+
+	tests := []struct {
+		name        string
+		url         string
+		disposition string
+	}{
+		{name: "Should force injected pages in uploads", url: "/files/uploads/evil/", disposition: `attachment; filename="index.html"`},
+		{name: "Should force executed pages in uploads", url: "/files/uploads/page.tmpl.html", disposition: `attachment; filename="page.tmpl.html"`},
+		{name: "Should serve injected pages elsewhere inline", url: "/files/"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := ut.PerformRequest(h.Engine, consts.MethodGet, tt.url, nil)
+			response := w.Result()
+			assert.DeepEqual(t, consts.StatusOK, response.StatusCode())
+			assert.DeepEqual(t, tt.disposition, string(response.Header.Peek("Content-Disposition")))
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package filesystem
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"html/template"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+)
+
+// ErrorPage answers the requests failing with a status, in place of the
+// text of the status. One of its fields is set.
+type ErrorPage struct {
+	// File is the path of a page in the root, served with the status.
+	File string
+	// Template is executed with the ErrorPageData of the request.
+	Template *template.Template
+	// Handler answers the request itself, the status being already set.
+	// It is called for all clients, JSON ones included.
+	Handler app.HandlerFunc
+}
+
+// ErrorPageData is the data the Template of an ErrorPage is executed with.
+type ErrorPageData struct {
+	Status int
+	// Title is the text of Status, such as "Not Found".
+	Title string
+	// Path is the request path.
+	Path string
+}
+
+// problemDetails is an RFC 9457 problem details object.
+type problemDetails struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// abortWithError answers the request with the error page of status, with
+// RFC 9457 problem details if the client prefers JSON, or with the text of
+// the status. The cause of the error is never written: it is logged where
+// it happens. cfg may be nil for errors happening before a root is chosen.
+func abortWithError(ctx context.Context, c *app.RequestContext, cfg *option, status int) {
+	defer c.Abort()
+	c.Response.Header.Add("Vary", "Accept")
+
+	var page ErrorPage
+	ok := false
+	if cfg != nil {
+		if page, ok = cfg.errorPages[status]; !ok {
+			page, ok = cfg.errorPages[0]
+		}
+	}
+	if ok && page.Handler != nil {
+		c.SetStatusCode(status)
+		page.Handler(ctx, c)
+		return
+	}
+	title := http.StatusText(status)
+	if title == "" {
+		title = strconv.Itoa(status)
+	}
+	if prefersProblemJSON(string(c.Request.Header.Peek("Accept"))) {
+		body, _ := json.Marshal(problemDetails{
+			Type:     "about:blank",
+			Title:    title,
+			Status:   status,
+			Instance: string(c.URI().Path()),
+		})
+		c.Data(status, "application/problem+json", body)
+		return
+	}
+	if ok && cfg.serveErrorPage(c, page, status, title) {
+		return
+	}
+	c.String(status, title)
+}
+
+// serveErrorPage answers the request with the File or the Template of
+// page. It returns false if the page cannot be rendered.
+func (o *option) serveErrorPage(c *app.RequestContext, page ErrorPage, status int, title string) bool {
+	if page.Template != nil {
+		var buf bytes.Buffer
+		data := ErrorPageData{Status: status, Title: title, Path: string(c.URI().Path())}
+		if err := page.Template.Execute(&buf, data); err != nil {
+			hlog.SystemLogger().Errorf("Failed to execute the error page of status %d: %s", status, err)
+			return false
+		}
+		c.Data(status, "text/html; charset=utf-8", buf.Bytes())
+		return true
+	}
+	if page.File == "" {
+		return false
+	}
+
+	file, err := o.open(o.pathPrefix + page.File)
+	if err != nil {
+		hlog.SystemLogger().Errorf("Failed to open the error page of status %d: %s", status, err)
+		return false
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil || stat.IsDir() {
+		hlog.SystemLogger().Errorf("Failed to open the error page of status %d: not a file", status)
+		return false
+	}
+	contentType := o.contentType(file, stat.Name())
+	body, err := io.ReadAll(file)
+	if err != nil {
+		hlog.SystemLogger().Errorf("Failed to read the error page of status %d: %s", status, err)
+		return false
+	}
+	c.Data(status, contentType, body)
+	return true
+}
+
+// prefersProblemJSON reports whether the Accept header prefers JSON over
+// HTML. Equal qualities go to HTML, so that browsers get pages.
+func prefersProblemJSON(accept string) bool {
+	return acceptQuality(accept, "application/problem+json", "application/json") >
+		acceptQuality(accept, "text/html")
+}
+
+// acceptQuality returns the highest quality the Accept header gives to
+// the media types, counting only exact ranges: a client sending "*/*"
+// states no preference.
+func acceptQuality(accept string, mediaTypes ...string) float64 {
+	best := 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(part, ";")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+		matched := false
+		for _, mt := range mediaTypes {
+			matched = matched || mediaType == mt
+		}
+		if !matched {
+			continue
+		}
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if v := strings.TrimSpace(param); strings.HasPrefix(v, "q=") {
+				if parsed, err := strconv.ParseFloat(strings.TrimPrefix(v, "q="), 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if q > best {
+			best = q
+		}
+	}
+	return best
+}
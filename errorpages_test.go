@@ -0,0 +1,97 @@
+package filesystem
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/test/assert"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+func TestErrorPages(t *testing.T) {
+	t.Parallel()
+
+	index, err := os.ReadFile("./examples/testdata/fs/index.html")
+	assert.Nil(t, err)
+
+	h := server.New()
+	NewFSHandler(h, "/plain", http.Dir("./examples/testdata/fs"))
+	pages := NewHandler(http.Dir("./examples/testdata/fs"), WithErrorPages(map[int]ErrorPage{
+		consts.StatusNotFound:   {File: "index.html"},
+		consts.StatusForbidden:  {Template: template.Must(template.New("403").Parse("{{.Status}} {{.Title}} {{.Path}}"))},
+		consts.StatusBadRequest: {File: "missing.html"},
+		0: {Handler: func(_ context.Context, c *app.RequestContext) {
+			c.SetBodyString("custom")
+		}},
+	}))
+	Register(h, "/pages", pages)
+	h.POST("/pages/*filepath", pages)
+
+	tests := []struct {
+		name        string
+		method      string
+		url         string
+		accept      string
+		statusCode  int
+		contentType string
+		body        string
+	}{
+		{name: "Should answer the status text", url: "/plain/missing", statusCode: 404, contentType: "text/plain; charset=utf-8", body: "Not Found"},
+		{name: "Should answer problem details to JSON clients", url: "/plain/missing", accept: "application/json", statusCode: 404, contentType: "application/problem+json", body: `{"type":"about:blank","title":"Not Found","status":404,"instance":"/plain/missing"}`},
+		{name: "Should prefer HTML on equal quality", url: "/plain/missing", accept: "text/html, application/problem+json", statusCode: 404, body: "Not Found"},
+		{name: "Should not expose the error of directories", url: "/plain/css/", statusCode: 403, body: "Forbidden"},
+		{name: "Should serve the page file", url: "/pages/missing", statusCode: 404, contentType: "text/html", body: string(index)},
+		{name: "Should answer problem details over the page file", url: "/pages/missing", accept: "application/problem+json", statusCode: 404, contentType: "application/problem+json", body: `{"type":"about:blank","title":"Not Found","status":404,"instance":"/pages/missing"}`},
+		{name: "Should execute the page template", url: "/pages/css/", statusCode: 403, contentType: "text/html; charset=utf-8", body: "403 Forbidden /pages/css/"},
+		{name: "Should call the default page handler", method: consts.MethodPost, url: "/pages/index.html", accept: "application/json", statusCode: 405, body: "custom"},
+		{name: "Should fall back to the status text", url: "/pages/x/%2e%2e/index.html", statusCode: 400, body: "Bad Request"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			method := tt.method
+			if method == "" {
+				method = consts.MethodGet
+			}
+			w := ut.PerformRequest(h.Engine, method, tt.url, nil, ut.Header{Key: "Accept", Value: tt.accept})
+			response := w.Result()
+			assert.DeepEqual(t, tt.statusCode, response.StatusCode())
+			if tt.contentType != "" {
+				assert.DeepEqual(t, tt.contentType, string(response.Header.ContentType()))
+			}
+			assert.DeepEqual(t, tt.body, string(response.Body()))
+		})
+	}
+}
+
+func TestPrefersProblemJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{accept: "", want: false},
+		{accept: "*/*", want: false},
+		{accept: "application/json", want: true},
+		{accept: "application/problem+json", want: true},
+		{accept: "text/html,application/xhtml+xml,*/*;q=0.8", want: false},
+		{accept: "text/html;q=0.5, application/json", want: true},
+		{accept: "application/json;q=0.5, text/html", want: false},
+		{accept: "application/json;q=0", want: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.accept, func(t *testing.T) {
+			t.Parallel()
+			assert.DeepEqual(t, tt.want, prefersProblemJSON(tt.accept))
+		})
+	}
+}
@@ -2,268 +2,375 @@ package filesystem
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"os"
-	"strconv"
 	"strings"
-	"sync"
+	"time"
 
 	"github.com/cloudwego/hertz/pkg/app"
 	"github.com/cloudwego/hertz/pkg/app/server"
 	"github.com/cloudwego/hertz/pkg/common/hlog"
 	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/cloudwego/hertz/pkg/route"
 )
 
 // NewFSHandler creates a new middleware handler.
 func NewFSHandler(engine *server.Hertz, relpath string, root http.FileSystem, opts ...Option) {
-	var prefix string
-	prefix = relpath
 	cfg := newOption(root, opts)
-	if cfg.pathPrefix != "" && !strings.HasPrefix(cfg.pathPrefix, "/") {
-		prefix = "/" + cfg.pathPrefix
+	var methods []string
+	if cfg.methodNotAllowed {
+		methods = OtherMethods
 	}
-	cacheControlStr := "public, max-age=" + strconv.Itoa(cfg.maxAge)
+	Register(engine, relpath, newHandler(cfg), methods...)
+}
+
+// NewHandler returns a handler serving root, to be registered on routes
+// whose "*filepath" parameter, or the one set by WithPathParam, holds the
+// path of the file. The part of the request path before it is the prefix
+// the handler is mounted on.
+//
+// Unlike NewFSHandler, it can be registered on route groups, behind
+// middleware, or with Register.
+func NewHandler(root http.FileSystem, opts ...Option) app.HandlerFunc {
+	return newHandler(newOption(root, opts))
+}
+
+func newHandler(cfg *option) app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		relPath := c.Param(cfg.pathParam)
+		serve(ctx, c, cfg, routeMount(c, relPath), relPath)
+	}
+}
+
+// routeMount returns the path prefix of the route of the request, the
+// request path without relPath, the part matched by the route parameter.
+func routeMount(c *app.RequestContext, relPath string) string {
+	return trimRight(strings.TrimSuffix(string(c.Path()), relPath), '/')
+}
+
+// Register registers handler on the paths under prefix, through a
+// "*filepath" route parameter, for GET, HEAD and OPTIONS and for methods.
+// Other methods routed to handler, such as with OtherMethods, are answered
+// with a 405 and the Allow header for existing files.
+func Register(router route.IRoutes, prefix string, handler app.HandlerFunc, methods ...string) {
+	for _, method := range registeredMethods {
+		router.Handle(method, prefix+"/*filepath", handler)
+	}
+	for _, method := range methods {
+		router.Handle(method, prefix+"/*filepath", handler)
+	}
+}
+
+// New creates a new middleware handler.
+//
+// Deprecated: use NewHandler or NewFSHandler instead.
+func New(urlPrefix string, root http.FileSystem, opts ...Option) app.HandlerFunc {
+	cfg := newOption(root, opts)
 
-	logicFunc := func(ctx context.Context, c *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
 		method := string(c.Method())
+		relPath := strings.TrimPrefix(string(c.Path()), urlPrefix)
 
-		// Check that the request has the correct headers, and that the request is well-formed.
-		// If the request does not have the correct headers, or is malformed, return an error.
-		// Otherwise, return nil.
-		if cfg.preHandler != nil {
-			customFallback, ok := cfg.preHandler(ctx, c)
-			if !ok {
-				if customFallback == nil {
-					c.AbortWithStatus(consts.StatusUnauthorized)
-					return
-				}
-				customFallback()
+		// Don't execute middleware if method != "GET" OR "HEAD", unless
+		// the request is for a file of the root. Behind a pre-handler, only
+		// preflights are checked, so that other requests cannot tell which
+		// files exist.
+		if method != http.MethodGet && method != http.MethodHead &&
+			(cfg.preHandler != nil && !isPreflight(c) || !cfg.exists(c.URI().PathOriginal(), relPath)) {
+			c.Next(ctx)
+			return
+		}
+		serve(ctx, c, cfg, urlPrefix, relPath)
+	}
+}
+
+// serve serves the file of cfg at relPath, the decoded request path
+// relative to mount, the path prefix the handler is mounted on.
+func serve(ctx context.Context, c *app.RequestContext, cfg *option, mount, relPath string) {
+	entry := newAccessLogEntry(c, cfg, mount)
+	if entry != nil {
+		defer logAccess(c, cfg, entry)
+	}
+
+	// Check that the request has the correct headers, and that the request is well-formed.
+	// If the request does not have the correct headers, or is malformed, return an error.
+	// Otherwise, return nil.
+	// CORS preflights never carry credentials, so they skip the pre-handler.
+	if cfg.preHandler != nil && !isPreflight(c) {
+		customFallback, ok := cfg.preHandler(ctx, c)
+		if !ok {
+			if customFallback == nil {
+				abortWithError(ctx, c, cfg, consts.StatusUnauthorized)
 				return
 			}
+			customFallback()
+			return
 		}
+	}
 
-		path := c.Param("filepath")
-		if !strings.HasPrefix(path, "/") {
-			path = "/" + path
-		}
+	method := string(c.Method())
+	if cfg.liveReload != nil && method == consts.MethodGet && "/"+strings.TrimPrefix(relPath, "/") == cfg.liveReload.path {
+		cfg.liveReload.serveEvents(c)
+		return
+	}
+	if method != consts.MethodGet && method != consts.MethodHead {
+		serveOtherMethod(ctx, c, cfg, relPath)
+		return
+	}
 
-		if cfg.pathPrefix != "" {
-			// PathPrefix already has a "/" relpath
-			path = cfg.pathPrefix + path
-		}
-		if len(path) > 1 {
-			path = trimRight(path, '/')
-		}
+	path, err := cleanRequestPath(c.URI().PathOriginal(), relPath, cfg.pathNormalizer)
+	if err != nil {
+		hlog.SystemLogger().Warnf("Rejected request path %q: %s", c.URI().PathOriginal(), err)
+		abortWithError(ctx, c, cfg, consts.StatusBadRequest)
+		return
+	}
+	urlPath := path
 
-		file, err := cfg.root.Open(path)
-		if err != nil && os.IsNotExist(err) && cfg.notFoundFile != "" {
-			file, err = cfg.root.Open(cfg.notFoundFile)
-		}
-		if err != nil {
-			if os.IsNotExist(err) {
-				hlog.SystemLogger().Errorf("Cannot open file or Directory, path: %s, err = %s", path, err)
-				c.AbortWithMsg("Cannot open file or Directory", consts.StatusNotFound)
-				return
-			}
-			hlog.SystemLogger().Errorf("Failed to open: %s", err)
-			c.AbortWithMsg("Cannot open file or Directory", consts.StatusNotFound)
+	if cfg.pathPrefix != "" {
+		// PathPrefix already has a "/" prefix
+		path = cfg.pathPrefix + path
+	}
+	if len(path) > 1 {
+		path = trimRight(path, '/')
+	}
+
+	// Redirect and rewrite rules are evaluated before the file is opened
+	status, rewritten := consts.StatusOK, false
+	if result, ok := evalRedirects(c, cfg, urlPath, func() bool { return cfg.isFile(path) }); ok {
+		if result.redirect {
+			c.Redirect(result.status, []byte(result.location(c, mount)))
 			return
 		}
+		urlPath, path = cfg.rewrite(result.target)
+		status, rewritten = result.status, true
+	}
 
-		stat, err := file.Stat()
-		if err != nil {
-			hlog.SystemLogger().Errorf("failed to stat: %s", err)
-			c.AbortWithMsg("failed to stat", consts.StatusInternalServerError)
+	hc := newHookContext(ctx, c, cfg, mount)
+	if hc != nil {
+		hc.Path = urlPath
+		if !cfg.hooks.resolve(hc) {
 			return
 		}
+		if hc.Path != urlPath {
+			urlPath, path = cfg.rewrite(hc.resolvedPath())
+			rewritten = true
+		}
+	}
 
-		// Serve index if relpath is directory
-		if stat.IsDir() {
-			indexPath := trimRight(path, '/') + cfg.index
-			index, err := cfg.root.Open(indexPath)
-			if err == nil {
-				indexStat, err := index.Stat()
-				if err == nil {
-					file = index
-					stat = indexStat
+	res, err := cfg.resolve(path, cfg.negotiateLanguages(c, urlPath))
+	if err != nil && os.IsNotExist(err) && cfg.spa != nil && cfg.spa.isNavigation(c, urlPath) {
+		res, err = cfg.openFallback()
+	}
+	if err != nil && os.IsNotExist(err) && cfg.browse {
+		// Directories holding mount points are listed even if missing
+		if entries := cfg.mountEntries(urlPath); len(entries) > 0 {
+			if err := dirList(c, nil, entries); err != nil {
+				hlog.SystemLogger().Errorf("show dirList fail, err: %s", err)
+				if !cfg.hooks.error(hc, err) {
+					abortWithError(ctx, c, cfg, consts.StatusInternalServerError)
 				}
 			}
+			return
 		}
-
-		// Browse directory if no index found and browsing is enabled
-		if stat.IsDir() {
-			if cfg.browse {
-				if err := dirList(c, file); err != nil {
-					c.String(consts.StatusInternalServerError, err.Error())
-					hlog.Errorf("show dirList fail, err: %s", err)
-				}
+	}
+	if err != nil {
+		if os.IsNotExist(err) {
+			logNotFound(cfg, "Cannot open file or Directory, path: %s, err = %s", path, err)
+			if cfg.hooks.notFound(hc) {
 				return
 			}
-			c.AbortWithStatus(consts.StatusForbidden)
+			abortWithError(ctx, c, cfg, consts.StatusNotFound)
 			return
 		}
-
-		modTime := stat.ModTime()
-		contentLength := int(stat.Size())
-
-		c.Response.Header.SetContentType(getMIME(getFileExtension(stat.Name())))
-		if !modTime.IsZero() {
-			c.Response.Header.Set(consts.HeaderLastModified, modTime.UTC().Format(http.TimeFormat))
-		}
-
-		if method == consts.MethodGet {
-			if cfg.maxAge > 0 {
-				c.Response.Header.Set("Cache-Control", cacheControlStr)
-			}
-			c.Response.SetBodyStream(file, contentLength)
+		hlog.SystemLogger().Errorf("Failed to open: %s", err)
+		if cfg.hooks.error(hc, err) {
 			return
 		}
-
-		if method == consts.MethodHead {
-			c.Request.ResetBody()
-			c.Response.SkipBody = true
-			c.Response.Header.SetContentLength(contentLength)
-			if err := file.Close(); err != nil {
-				hlog.SystemLogger().Errorf("failed to close: %s", err.Error())
-				c.AbortWithMsg("fail to close file", consts.StatusInternalServerError)
-				return
-			}
+		if os.IsPermission(err) {
+			abortWithError(ctx, c, cfg, consts.StatusForbidden)
 			return
 		}
-		c.Next(ctx)
+		abortWithError(ctx, c, cfg, consts.StatusInternalServerError)
+		return
 	}
-	engine.GET(prefix+"/*filepath", logicFunc)
-	engine.HEAD(prefix+"/*filepath", logicFunc)
-}
-
-// New creates a new middleware handler.
-//
-// filesystem does not handle url encoded values (for example spaces)
-// on its own.
-//
-// Deprecated: use NewFSHandler instead.
-func New(urlPrefix string, root http.FileSystem, opts ...Option) app.HandlerFunc {
-	cfg := newOption(root, opts)
-
-	var once sync.Once
-	var prefix string
-	cacheControlStr := "public, max-age=" + strconv.Itoa(cfg.maxAge)
-
-	return func(ctx context.Context, c *app.RequestContext) {
-		method := string(c.Method())
-
-		// Don't execute middleware if method != "GET" OR "HEAD"
-		if method != http.MethodGet && method != http.MethodHead {
-			c.Next(ctx)
+	if res.file == nil {
+		abortWithError(ctx, c, cfg, res.status)
+		return
+	}
+	if !rewritten {
+		if canonical, ok := cfg.canonicalPath(urlPath, res); ok {
+			_ = res.file.Close()
+			c.Redirect(consts.StatusMovedPermanently, []byte(redirectLocation(c, mount, canonical, true)))
 			return
 		}
+	}
+	file, stat := res.file, res.stat
+	if entry != nil {
+		entry.File, entry.Size = res.name, stat.Size()
+	}
+	if res.status != 0 {
+		status = res.status
+	}
+	if !cfg.hooks.serve(hc, res.name, stat) {
+		_ = file.Close()
+		return
+	}
 
-		// Check that the request has the correct headers, and that the request is well-formed.
-		// If the request does not have the correct headers, or is malformed, return an error.
-		// Otherwise, return nil.
-		if cfg.preHandler != nil {
-			customFallbackFunc, ok := cfg.preHandler(ctx, c)
-			if !ok {
-				if customFallbackFunc != nil {
-					customFallbackFunc()
-					return
+	// Browse directory if no index found and browsing is enabled
+	if stat.IsDir() {
+		if cfg.browse {
+			if err := dirList(c, file, cfg.mountEntries(urlPath)); err != nil {
+				hlog.SystemLogger().Errorf("show dirList fail, err: %s", err)
+				if !cfg.hooks.error(hc, err) {
+					abortWithError(ctx, c, cfg, consts.StatusInternalServerError)
 				}
-				c.AbortWithStatus(consts.StatusUnauthorized)
-				return
 			}
+			return
 		}
+		abortWithError(ctx, c, cfg, consts.StatusForbidden)
+		return
+	}
 
-		once.Do(func() {
-			prefix = urlPrefix
-		})
+	modTime := stat.ModTime()
+	contentLength := int(stat.Size())
 
-		path := strings.TrimPrefix(string(c.Path()), prefix)
-		if !strings.HasPrefix(path, "/") {
-			path = "/" + path
+	dynamic := cfg.templates != nil && cfg.templates.executes(res.name)
+	inject := !dynamic && cfg.injection != nil && cfg.injection.applies(cfg, res)
+	cacheable := !dynamic && !inject && cfg.liveReload == nil
+	if status == consts.StatusOK && cacheable && !modTime.IsZero() && !c.IfModifiedSince(modTime) {
+		if err := file.Close(); err != nil {
+			hlog.SystemLogger().Errorf("failed to close: %s", err.Error())
 		}
+		c.NotModified()
+		setFileHeaders(c, cfg, urlPath, res)
+		cfg.hooks.headers(hc, consts.StatusNotModified)
+		return
+	}
 
-		if cfg.pathPrefix != "" {
-			// PathPrefix already has a "/" prefix
-			path = cfg.pathPrefix + path
-		}
-		if len(path) > 1 {
-			path = trimRight(path, '/')
-		}
-		file, err := cfg.root.Open(path)
-		if err != nil && os.IsNotExist(err) && cfg.notFoundFile != "" {
-			file, err = cfg.root.Open(cfg.notFoundFile)
-		}
+	if dynamic {
+		body, err := cfg.templates.execute(c, cfg, file, res.name, stat)
+		_ = file.Close()
 		if err != nil {
-			if os.IsNotExist(err) {
-				hlog.SystemLogger().Errorf("Cannot open file or Directory, path: %s, err = %s", path, err)
-				c.AbortWithMsg("Cannot open file or Directory", consts.StatusNotFound)
-				return
+			hlog.SystemLogger().Errorf("Failed to execute %s: %s", res.name, err)
+			if !cfg.hooks.error(hc, err) {
+				abortWithError(ctx, c, cfg, consts.StatusInternalServerError)
 			}
-			hlog.SystemLogger().Errorf("Failed to open: %s", err)
-			c.AbortWithMsg("Cannot open file or Directory", consts.StatusNotFound)
 			return
 		}
-
-		stat, err := file.Stat()
+		serveRendered(c, cfg, hc, mount, urlPath, res, status, body, time.Time{})
+		return
+	}
+	if inject {
+		body, etag, err := cfg.injection.inject(file, res.name, stat, mount)
+		_ = file.Close()
 		if err != nil {
-			hlog.SystemLogger().Errorf("failed to stat: %s", err)
-			c.AbortWithMsg("failed to stat", consts.StatusInternalServerError)
+			hlog.SystemLogger().Errorf("Failed to inject the config into %s: %s", res.name, err)
+			if !cfg.hooks.error(hc, err) {
+				abortWithError(ctx, c, cfg, consts.StatusInternalServerError)
+			}
 			return
 		}
-
-		// Serve index if urlPrefix is directory
-		if stat.IsDir() {
-			indexPath := trimRight(path, '/') + cfg.index
-			index, err := cfg.root.Open(indexPath)
-			if err == nil {
-				indexStat, err := index.Stat()
-				if err == nil {
-					file = index
-					stat = indexStat
-				}
+		// The ETag replaces Last-Modified, the page changing with the config
+		if status == consts.StatusOK && cfg.liveReload == nil && etagMatches(string(c.Request.Header.Peek("If-None-Match")), etag) {
+			c.NotModified()
+			c.Response.Header.Set("ETag", etag)
+			setFileHeaders(c, cfg, urlPath, res)
+			cfg.hooks.headers(hc, consts.StatusNotModified)
+			return
+		}
+		c.Response.Header.Set("ETag", etag)
+		serveRendered(c, cfg, hc, mount, urlPath, res, status, body, time.Time{})
+		return
+	}
+	if cfg.markdown != nil && cfg.markdown.renders(c, res.name) {
+		body, err := cfg.markdown.render(file, res.name, stat)
+		_ = file.Close()
+		if err != nil {
+			hlog.SystemLogger().Errorf("Failed to render %s: %s", res.name, err)
+			if !cfg.hooks.error(hc, err) {
+				abortWithError(ctx, c, cfg, consts.StatusInternalServerError)
 			}
+			return
 		}
-
-		// Browse directory if no index found and browsing is enabled
-		if stat.IsDir() {
-			if cfg.browse {
-				if err := dirList(c, file); err != nil {
-					c.String(consts.StatusInternalServerError, err.Error())
-					hlog.Errorf("show dirList fail, err: %s", err)
-				}
-				return
+		serveRendered(c, cfg, hc, mount, urlPath, res, status, body, modTime)
+		return
+	}
+	if cfg.liveReload != nil && isHTML(res.name) {
+		body, err := io.ReadAll(file)
+		_ = file.Close()
+		if err != nil {
+			hlog.SystemLogger().Errorf("Failed to read %s: %s", res.name, err)
+			if !cfg.hooks.error(hc, err) {
+				abortWithError(ctx, c, cfg, consts.StatusInternalServerError)
 			}
-			c.AbortWithStatus(consts.StatusForbidden)
 			return
 		}
+		serveRendered(c, cfg, hc, mount, urlPath, res, status, body, time.Time{})
+		return
+	}
 
-		modTime := stat.ModTime()
-		contentLength := int(stat.Size())
-
-		c.Response.Header.SetContentType(getMIME(getFileExtension(stat.Name())))
-		if !modTime.IsZero() {
-			c.Response.Header.Set(consts.HeaderLastModified, modTime.UTC().Format(http.TimeFormat))
-		}
+	c.Response.Header.SetContentType(cfg.contentType(file, stat.Name()))
+	if cfg.disposition != nil {
+		setDisposition(c, cfg, urlPath, res.name, string(c.Response.Header.ContentType()))
+	}
+	if !modTime.IsZero() {
+		c.Response.Header.Set(consts.HeaderLastModified, modTime.UTC().Format(http.TimeFormat))
+	}
+	setFileHeaders(c, cfg, urlPath, res)
+	cfg.hooks.headers(hc, status)
+	c.Response.SetStatusCode(status)
 
-		if method == consts.MethodGet {
-			if cfg.maxAge > 0 {
-				c.Response.Header.Set("Cache-Control", cacheControlStr)
-			}
-			c.Response.SetBodyStream(file, contentLength)
+	if method == consts.MethodGet {
+		if cfg.mappings != nil && serveMmap(ctx, c, cfg, file, stat) {
 			return
 		}
+		var body io.Reader = file
+		if cfg.metrics != nil {
+			body = cfg.metrics.trackFile(file)
+		}
+		c.Response.SetBodyStream(body, contentLength)
+		return
+	}
 
-		if method == consts.MethodHead {
-			c.Request.ResetBody()
-			c.Response.SkipBody = true
-			c.Response.Header.SetContentLength(contentLength)
-			if err := file.Close(); err != nil {
-				hlog.SystemLogger().Errorf("failed to close: %s", err.Error())
-				c.AbortWithMsg("fail to close file", consts.StatusInternalServerError)
-				return
-			}
+	if method == consts.MethodHead {
+		c.Request.ResetBody()
+		c.Response.SkipBody = true
+		c.Response.Header.SetContentLength(contentLength)
+		if err := file.Close(); err != nil {
+			hlog.SystemLogger().Errorf("failed to close: %s", err.Error())
+			abortWithError(ctx, c, cfg, consts.StatusInternalServerError)
 			return
 		}
-		c.Next(ctx)
+		return
+	}
+	c.Next(ctx)
+}
+
+// serveRendered answers the request with body, an HTML page rendered from
+// the file of res, with the headers the file would have been served with,
+// its Content-Disposition included. modTime is the Last-Modified of the
+// page, zero if it has none. The live reload client of the handler mounted
+// on mount is added to the page.
+func serveRendered(c *app.RequestContext, cfg *option, hc *HookContext, mount, urlPath string, res resolved, status int, body []byte, modTime time.Time) {
+	const contentType = "text/html; charset=utf-8"
+	if cfg.liveReload != nil {
+		body = cfg.liveReload.inject(body, mount)
+	}
+	c.Response.Header.SetContentType(contentType)
+	if cfg.disposition != nil {
+		setDisposition(c, cfg, urlPath, res.name, contentType)
+	}
+	if !modTime.IsZero() {
+		c.Response.Header.Set(consts.HeaderLastModified, modTime.UTC().Format(http.TimeFormat))
+	}
+	setFileHeaders(c, cfg, urlPath, res)
+	cfg.hooks.headers(hc, status)
+	c.Response.SetStatusCode(status)
+	if string(c.Method()) == consts.MethodHead {
+		c.Response.SkipBody = true
+		c.Response.Header.SetContentLength(len(body))
+		return
 	}
+	c.Response.SetBody(body)
 }
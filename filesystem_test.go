@@ -16,7 +16,7 @@ func TestFileSystem(t *testing.T) {
 
 	h := server.New()
 	NewFSHandler(h, "/test", http.Dir("./examples/testdata/fs"))
-	NewFSHandler(h, "/dir", http.Dir("./examples/testdata/fs"))
+	NewFSHandler(h, "/dir", http.Dir("./examples/testdata/fs"), WithBrowse(true))
 	h.GET("/", func(ctx context.Context, c *app.RequestContext) { c.String(200, "Hello World!") })
 	NewFSHandler(h, "/spatest", http.Dir("./examples/testdata/fs"), WithIndexFile("index.html"), WithNotFoundFile("index.html"))
 	NewFSHandler(h, "/prefix", http.Dir("./examples/testdata/fs"), WithPathPrefix("img"))
@@ -35,7 +35,7 @@ func TestFileSystem(t *testing.T) {
 		},
 		{
 			name:        "Should be returns status 200 with suitable content-type",
-			url:         "/test",
+			url:         "/test/",
 			statusCode:  200,
 			contentType: "text/html",
 		},
@@ -98,6 +98,7 @@ func TestFileSystem(t *testing.T) {
 		},
 	}
 	for _, tt := range tests {
+		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 			w := ut.PerformRequest(h.Engine, consts.MethodGet, tt.url, nil)
@@ -126,3 +127,43 @@ func TestPreHandler(t *testing.T) {
 	response := w.Result()
 	assert.DeepEqual(t, 401, response.StatusCode())
 }
+
+func TestNewHandler(t *testing.T) {
+	t.Parallel()
+
+	h := server.New()
+	group := h.Group("/group", func(ctx context.Context, c *app.RequestContext) {
+		c.Response.Header.Set("X-Group", "1")
+		c.Next(ctx)
+	})
+	Register(group, "/static", NewHandler(http.Dir("./examples/testdata/fs")))
+	h.GET("/files/:name", NewHandler(http.Dir("./examples/testdata/fs/css"), WithPathParam("name")))
+	h.Any("/any/*filepath", NewHandler(http.Dir("./examples/testdata/fs"), WithTrailingSlash(TrailingSlashAlways)))
+
+	tests := []struct {
+		name       string
+		method     string
+		url        string
+		statusCode int
+		header     string
+		location   string
+	}{
+		{name: "Should serve in a route group", method: consts.MethodGet, url: "/group/static/css/style.css", statusCode: 200, header: "1"},
+		{name: "Should serve HEAD in a route group", method: consts.MethodHead, url: "/group/static/", statusCode: 200, header: "1"},
+		{name: "Should serve a custom route parameter", method: consts.MethodGet, url: "/files/style.css", statusCode: 200},
+		{name: "Should redirect relative to the route", method: consts.MethodGet, url: "/any/css", statusCode: 301, location: "/any/css/"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := ut.PerformRequest(h.Engine, tt.method, tt.url, nil)
+			response := w.Result()
+			assert.DeepEqual(t, tt.statusCode, response.StatusCode())
+			assert.DeepEqual(t, tt.header, response.Header.Get("X-Group"))
+			if tt.location != "" {
+				assert.DeepEqual(t, tt.location, response.Header.Get("Location"))
+			}
+		})
+	}
+}
@@ -2,4 +2,19 @@ module github.com/Skyenought/filesystem
 
 go 1.18
 
-require github.com/cloudwego/hertz v0.10.0
+require (
+	github.com/cloudwego/hertz v0.10.0
+	golang.org/x/text v0.14.0
+)
+
+require (
+	github.com/bytedance/gopkg v0.1.1 // indirect
+	github.com/bytedance/sonic v1.13.2 // indirect
+	github.com/cloudwego/gopkg v0.1.4 // indirect
+	github.com/cloudwego/netpoll v0.7.0 // indirect
+	github.com/fsnotify/fsnotify v1.5.4 // indirect
+	github.com/golang/protobuf v1.5.0 // indirect
+	github.com/nyaruka/phonenumbers v1.0.55 // indirect
+	golang.org/x/sys v0.24.0 // indirect
+	google.golang.org/protobuf v1.27.1 // indirect
+)
@@ -0,0 +1,112 @@
+package filesystem
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// headerRule is a block of a _headers file: the headers set on the
+// responses whose path matches the pattern.
+type headerRule struct {
+	pattern pathPattern
+	headers []header
+}
+
+type header struct {
+	name  string
+	value string
+}
+
+// parseHeaderRules parses a _headers file, made of path patterns each
+// followed by the indented header lines applied to them:
+//
+//	# Comments start with a hash.
+//	/*
+//	  X-Frame-Options: DENY
+//	/fonts/*
+//	  Access-Control-Allow-Origin: *
+//
+// A header repeated within a block is sent once, with the values joined
+// by commas.
+func parseHeaderRules(r io.Reader) (interface{}, error) {
+	var rules []headerRule
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "/") {
+			rules = append(rules, headerRule{pattern: compilePattern(line)})
+			continue
+		}
+		if len(rules) == 0 {
+			return nil, fmt.Errorf("line %d: header without a path", n)
+		}
+		name, value, ok := strings.Cut(line, ":")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("line %d: malformed header %q", n, line)
+		}
+
+		rule := &rules[len(rules)-1]
+		joined := false
+		for i := range rule.headers {
+			if strings.EqualFold(rule.headers[i].name, name) {
+				rule.headers[i].value += ", " + value
+				joined = true
+				break
+			}
+		}
+		if !joined {
+			rule.headers = append(rule.headers, header{name: name, value: value})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// setRuleHeaders sets the headers of every rule matching urlPath, later
+// rules overriding the headers set by earlier ones.
+func setRuleHeaders(c *app.RequestContext, rf *rulesFile, urlPath string) {
+	rules, _ := rf.load().([]headerRule)
+	for _, rule := range rules {
+		params, ok := rule.pattern.match(urlPath)
+		if !ok {
+			continue
+		}
+		for _, h := range rule.headers {
+			c.Response.Header.Set(h.name, expandParams(h.value, params))
+		}
+	}
+}
+
+// setFileHeaders sets the caching, language, CORS and nosniff headers and the
+// headers of the matching _headers rules on a response serving res for
+// urlPath.
+func setFileHeaders(c *app.RequestContext, cfg *option, urlPath string, res resolved) {
+	setCacheHeaders(c, cfg, res.name)
+	if res.fallback {
+		setFallbackHeaders(c)
+	}
+	setLanguageHeaders(c, cfg, res)
+	if cfg.cors != nil {
+		setCORSHeaders(c, cfg.cors)
+	}
+	if cfg.noSniff {
+		c.Response.Header.Set("X-Content-Type-Options", "nosniff")
+	}
+	if cfg.headerRules != nil {
+		setRuleHeaders(c, cfg.headerRules, urlPath)
+	}
+	if cfg.liveReload != nil {
+		c.Response.Header.Set("Cache-Control", "no-store")
+		c.Response.Header.Del("Expires")
+	}
+}
@@ -0,0 +1,151 @@
+package filesystem
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/test/assert"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+func TestParseHeaderRules(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		input   string
+		rules   int
+		headers []header
+		wantErr bool
+	}{
+		{
+			name:    "Should parse a block",
+			input:   "# comment\n/*\n  X-Frame-Options: DENY\n  Link: </a.css>; rel=preload\n",
+			rules:   1,
+			headers: []header{{"X-Frame-Options", "DENY"}, {"Link", "</a.css>; rel=preload"}},
+		},
+		{
+			name:    "Should join repeated headers",
+			input:   "/a\n  Link: </a.css>\n  link: </b.css>\n/b\n  X-A: 1\n",
+			rules:   2,
+			headers: []header{{"Link", "</a.css>, </b.css>"}},
+		},
+		{
+			name:    "Should reject a header without a path",
+			input:   "X-Frame-Options: DENY\n",
+			wantErr: true,
+		},
+		{
+			name:    "Should reject a malformed header",
+			input:   "/*\n  X-Frame-Options\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			value, err := parseHeaderRules(strings.NewReader(tt.input))
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			rules := value.([]headerRule)
+			assert.DeepEqual(t, tt.rules, len(rules))
+			assert.DeepEqual(t, tt.headers, rules[0].headers)
+		})
+	}
+}
+
+func TestPathPattern(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pattern string
+		path    string
+		ok      bool
+		params  map[string]string
+	}{
+		{"/", "/", true, nil},
+		{"/about", "/about/", true, nil},
+		{"/about", "/about/team", false, nil},
+		{"/*", "/", true, map[string]string{"splat": ""}},
+		{"/blog/*", "/blog/2023/post", true, map[string]string{"splat": "2023/post"}},
+		{"/blog/*", "/news/post", false, nil},
+		{"/users/:id/posts", "/users/42/posts", true, map[string]string{"id": "42"}},
+		{"/users/:id", "/users", false, nil},
+	}
+	for _, tt := range tests {
+		params, ok := compilePattern(tt.pattern).match(tt.path)
+		assert.DeepEqual(t, tt.ok, ok)
+		assert.DeepEqual(t, tt.params, params)
+	}
+}
+
+func TestHeadersFile(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "index.html"), []byte("<html></html>"), 0o644))
+	assert.Nil(t, os.MkdirAll(filepath.Join(root, "fonts"), 0o755))
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "fonts", "a.woff2"), []byte("font"), 0o644))
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "_headers"), []byte(`
+/*
+  X-Frame-Options: DENY
+/fonts/:name
+  Access-Control-Allow-Origin: *
+  X-Font: :name
+`), 0o644))
+
+	h := server.New()
+	NewFSHandler(h, "/site", http.Dir(root), WithHeadersFile("_headers"))
+
+	w := ut.PerformRequest(h.Engine, consts.MethodGet, "/site/", nil)
+	response := w.Result()
+	assert.DeepEqual(t, 200, response.StatusCode())
+	assert.DeepEqual(t, "DENY", response.Header.Get("X-Frame-Options"))
+	assert.DeepEqual(t, "", response.Header.Get("Access-Control-Allow-Origin"))
+
+	w = ut.PerformRequest(h.Engine, consts.MethodHead, "/site/fonts/a.woff2", nil)
+	response = w.Result()
+	assert.DeepEqual(t, "DENY", response.Header.Get("X-Frame-Options"))
+	assert.DeepEqual(t, "*", response.Header.Get("Access-Control-Allow-Origin"))
+	assert.DeepEqual(t, "a.woff2", response.Header.Get("X-Font"))
+
+	w = ut.PerformRequest(h.Engine, consts.MethodGet, "/site/_headers", nil)
+	assert.DeepEqual(t, 404, w.Result().StatusCode())
+}
+
+func TestRulesFileReload(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	name := filepath.Join(dir, "_headers")
+	assert.Nil(t, os.WriteFile(name, []byte("/*\n  X-A: 1\n"), 0o644))
+
+	rf := newRulesFile(http.Dir(dir), "_headers", parseHeaderRules)
+	assert.DeepEqual(t, 1, len(rf.load().([]headerRule)))
+
+	assert.Nil(t, os.WriteFile(name, []byte("/*\n  X-A: 1\n/b\n  X-B: 2\n"), 0o644))
+	assert.Nil(t, os.Chtimes(name, time.Now(), time.Now().Add(time.Minute)))
+	assert.DeepEqual(t, 1, len(rf.load().([]headerRule)))
+
+	rf.checked = time.Time{}
+	assert.DeepEqual(t, 2, len(rf.load().([]headerRule)))
+
+	assert.Nil(t, os.WriteFile(name, []byte("X-C: 3\n"), 0o644))
+	assert.Nil(t, os.Chtimes(name, time.Now(), time.Now().Add(2*time.Minute)))
+	rf.checked = time.Time{}
+	assert.DeepEqual(t, 2, len(rf.load().([]headerRule)))
+
+	assert.Nil(t, os.Remove(name))
+	rf.checked = time.Time{}
+	assert.Nil(t, rf.load())
+}
@@ -0,0 +1,212 @@
+package filesystem
+
+import (
+	"html"
+	"strings"
+)
+
+// syntax is what highlightCode knows of the syntax of a language.
+type syntax struct {
+	lineComments []string
+	// blockComment is the opening and closing delimiters of block comments.
+	blockComment [2]string
+	// quotes are the delimiters of strings.
+	quotes   string
+	keywords map[string]bool
+	// foldCase makes keywords case-insensitive.
+	foldCase bool
+}
+
+func foldCase(s *syntax) *syntax {
+	s.foldCase = true
+	return s
+}
+
+func newSyntax(lineComments []string, blockComment [2]string, quotes, keywords string) *syntax {
+	s := &syntax{lineComments: lineComments, blockComment: blockComment, quotes: quotes, keywords: make(map[string]bool)}
+	for _, kw := range strings.Fields(keywords) {
+		s.keywords[kw] = true
+	}
+	return s
+}
+
+var (
+	cComment = [2]string{"/*", "*/"}
+
+	syntaxes = map[string]*syntax{
+		"go": newSyntax([]string{"//"}, cComment, "\"'`",
+			"break case chan const continue default defer else fallthrough for func go goto if import interface "+
+				"map package range return select struct switch type var true false nil iota"),
+		"javascript": newSyntax([]string{"//"}, cComment, "\"'`",
+			"async await break case catch class const continue debugger default delete do else export extends "+
+				"finally for function if import in instanceof let new of return super switch this throw try typeof "+
+				"var void while with yield null undefined true false"),
+		"typescript": newSyntax([]string{"//"}, cComment, "\"'`",
+			"abstract as async await break case catch class const continue debugger declare default delete do else "+
+				"enum export extends finally for function if implements import in instanceof interface keyof let new "+
+				"of private protected public readonly return super switch this throw try type typeof var void while "+
+				"yield null undefined true false"),
+		"python": newSyntax([]string{"#"}, [2]string{}, "\"'",
+			"and as assert async await break class continue def del elif else except finally for from global if "+
+				"import in is lambda nonlocal not or pass raise return try while with yield None True False"),
+		"shell": newSyntax([]string{"#"}, [2]string{}, "\"'",
+			"if then else elif fi case esac for while until do done in function return export local"),
+		"rust": newSyntax([]string{"//"}, cComment, "\"",
+			"as async await break const continue crate dyn else enum extern false fn for if impl in let loop match "+
+				"mod move mut pub ref return self Self static struct super trait true type unsafe use where while"),
+		"c": newSyntax([]string{"//"}, cComment, "\"'",
+			"auto break case char const continue default do double else enum extern float for goto if inline int "+
+				"long register return short signed sizeof static struct switch typedef union unsigned void volatile "+
+				"while bool class delete namespace new nullptr private protected public template this throw try catch "+
+				"using virtual true false"),
+		"java": newSyntax([]string{"//"}, cComment, "\"'",
+			"abstract boolean break byte case catch char class const continue default do double else enum extends "+
+				"final finally float for if implements import instanceof int interface long new package private "+
+				"protected public return short static super switch synchronized this throw throws try var void "+
+				"volatile while null true false"),
+		"sql": foldCase(newSyntax([]string{"--"}, cComment, "'\"",
+			"select from where insert into update delete create table drop alter join left right inner outer on "+
+				"group by order having limit offset and or not null is in as values set index primary key default "+
+				"distinct union all case when then else end")),
+		"json": newSyntax(nil, [2]string{}, "\"", "true false null"),
+		"yaml": newSyntax([]string{"#"}, [2]string{}, "\"'", "true false null yes no"),
+	}
+
+	syntaxAliases = map[string]string{
+		"golang":  "go",
+		"js":      "javascript",
+		"jsx":     "javascript",
+		"mjs":     "javascript",
+		"ts":      "typescript",
+		"tsx":     "typescript",
+		"py":      "python",
+		"sh":      "shell",
+		"bash":    "shell",
+		"zsh":     "shell",
+		"console": "shell",
+		"rs":      "rust",
+		"h":       "c",
+		"cpp":     "c",
+		"c++":     "c",
+		"cc":      "c",
+		"yml":     "yaml",
+	}
+)
+
+// highlightCode returns code as HTML, with its comments, strings, numbers
+// and keywords in spans of the classes hl-comment, hl-string, hl-number
+// and hl-keyword. Code of an unknown language is only escaped.
+func highlightCode(lang, code string) string {
+	lang = strings.ToLower(lang)
+	if alias, ok := syntaxAliases[lang]; ok {
+		lang = alias
+	}
+	syn := syntaxes[lang]
+	if syn == nil {
+		return html.EscapeString(code)
+	}
+
+	var b strings.Builder
+	span := func(class, s string) {
+		b.WriteString(`<span class="hl-`)
+		b.WriteString(class)
+		b.WriteString(`">`)
+		b.WriteString(html.EscapeString(s))
+		b.WriteString("</span>")
+	}
+	for i := 0; i < len(code); {
+		rest := code[i:]
+		if syn.isLineComment(rest) {
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			span("comment", rest[:end])
+			i += end
+			continue
+		}
+		if open, closing := syn.blockComment[0], syn.blockComment[1]; open != "" && strings.HasPrefix(rest, open) {
+			end := len(rest)
+			if n := strings.Index(rest[len(open):], closing); n >= 0 {
+				end = len(open) + n + len(closing)
+			}
+			span("comment", rest[:end])
+			i += end
+			continue
+		}
+		ch := rest[0]
+		switch {
+		case strings.IndexByte(syn.quotes, ch) >= 0:
+			n := stringLen(rest)
+			span("string", rest[:n])
+			i += n
+		case isDigit(ch):
+			n := 1
+			for n < len(rest) && (isIdentByte(rest[n]) || rest[n] == '.') {
+				n++
+			}
+			span("number", rest[:n])
+			i += n
+		case isIdentByte(ch):
+			n := 1
+			for n < len(rest) && isIdentByte(rest[n]) {
+				n++
+			}
+			word := rest[:n]
+			if syn.foldCase {
+				word = strings.ToLower(word)
+			}
+			if syn.keywords[word] {
+				span("keyword", rest[:n])
+			} else {
+				b.WriteString(rest[:n])
+			}
+			i += n
+		default:
+			b.WriteString(html.EscapeString(rest[:1]))
+			i++
+		}
+	}
+	return b.String()
+}
+
+func (s *syntax) isLineComment(code string) bool {
+	for _, prefix := range s.lineComments {
+		if strings.HasPrefix(code, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// stringLen returns the length of the string literal code starts with,
+// up to the end of the line if it is not closed. Backquoted strings span
+// lines and have no escapes.
+func stringLen(code string) int {
+	quote := code[0]
+	for i := 1; i < len(code); i++ {
+		switch code[i] {
+		case quote:
+			return i + 1
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case '\n':
+			if quote != '`' {
+				return i
+			}
+		}
+	}
+	return len(code)
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+// isIdentByte reports whether ch belongs to an identifier, the bytes of
+// non-ASCII characters included.
+func isIdentByte(ch byte) bool {
+	return ch == '_' || isDigit(ch) || (ch|0x20 >= 'a' && ch|0x20 <= 'z') || ch >= 0x80
+}
@@ -0,0 +1,117 @@
+package filesystem
+
+import (
+	"context"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+// HookContext is the state of a request passed to the hooks of WithHooks.
+type HookContext struct {
+	Context        context.Context
+	RequestContext *app.RequestContext
+	// Mount is the path prefix of the handler.
+	Mount string
+	// Path is the request path relative to the handler, after the rewrite
+	// rules. OnResolve may change it.
+	Path string
+	// Name is the path in the root of the file served, from OnServe on.
+	Name string
+	// Info is the file served, from OnServe on.
+	Info os.FileInfo
+	// Status is the status of the response, from OnHeaders on.
+	Status int
+	// Err is the error of OnError.
+	Err error
+
+	cfg *option
+}
+
+// Hooks are called at each stage of serving a request. A nil hook is
+// skipped.
+//
+// The hooks returning false veto the request: they are expected to answer
+// it, and it is answered with a 403 if they did not abort it.
+type Hooks struct {
+	// OnResolve is called with the requested path before the file is
+	// looked up. It may change Path to serve another file.
+	OnResolve func(hc *HookContext) bool
+	// OnServe is called once the file is opened, with its Name and Info.
+	OnServe func(hc *HookContext) bool
+	// OnHeaders is called before the headers of the file are written, to
+	// add headers to the response of RequestContext.
+	OnHeaders func(hc *HookContext)
+	// OnNotFound is called when no file is found. It returns true if it
+	// answered the request, which is otherwise answered with a 404.
+	OnNotFound func(hc *HookContext) bool
+	// OnError is called when a file cannot be served because of Err. It
+	// returns true if it answered the request.
+	OnError func(hc *HookContext) bool
+}
+
+// newHookContext returns the context of the hooks of the request, or nil
+// if there are no hooks.
+func newHookContext(ctx context.Context, c *app.RequestContext, cfg *option, mount string) *HookContext {
+	if cfg.hooks == nil {
+		return nil
+	}
+	return &HookContext{Context: ctx, RequestContext: c, Mount: mount, cfg: cfg}
+}
+
+func (h *Hooks) resolve(hc *HookContext) bool {
+	if h == nil || h.OnResolve == nil {
+		return true
+	}
+	return vetoUnless(hc, h.OnResolve(hc))
+}
+
+// resolvedPath returns the Path set by OnResolve, cleaned so that it stays
+// in the root.
+func (hc *HookContext) resolvedPath() string {
+	cleaned := path.Clean("/" + hc.Path)
+	if cleaned != "/" && strings.HasSuffix(hc.Path, "/") {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+func (h *Hooks) serve(hc *HookContext, name string, info os.FileInfo) bool {
+	if h == nil || h.OnServe == nil {
+		return true
+	}
+	hc.Name, hc.Info = name, info
+	return vetoUnless(hc, h.OnServe(hc))
+}
+
+func (h *Hooks) headers(hc *HookContext, status int) {
+	if h == nil || h.OnHeaders == nil {
+		return
+	}
+	hc.Status = status
+	h.OnHeaders(hc)
+}
+
+func (h *Hooks) notFound(hc *HookContext) bool {
+	return h != nil && h.OnNotFound != nil && h.OnNotFound(hc)
+}
+
+func (h *Hooks) error(hc *HookContext, err error) bool {
+	if h == nil || h.OnError == nil {
+		return false
+	}
+	hc.Err = err
+	return h.OnError(hc)
+}
+
+// vetoUnless answers the request with a 403 if a hook vetoed it without
+// answering it.
+func vetoUnless(hc *HookContext, ok bool) bool {
+	if !ok && !hc.RequestContext.IsAborted() {
+		abortWithError(hc.Context, hc.RequestContext, hc.cfg, consts.StatusForbidden)
+	}
+	return ok
+}
@@ -0,0 +1,85 @@
+package filesystem
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/test/assert"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+func TestHooks(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var served []string
+	h := server.New()
+	NewFSHandler(h, "/hooks", http.Dir("./examples/testdata/fs"), WithHooks(Hooks{
+		OnResolve: func(hc *HookContext) bool {
+			switch {
+			case hc.Path == "/variant-b":
+				hc.Path = "/css/style.css"
+			case hc.Path == "/escape":
+				hc.Path = "/../../go.mod"
+			case strings.HasPrefix(hc.Path, "/private/"):
+				return false
+			case hc.Path == "/teapot":
+				hc.RequestContext.AbortWithStatus(consts.StatusTeapot)
+				return false
+			}
+			return true
+		},
+		OnServe: func(hc *HookContext) bool {
+			mu.Lock()
+			served = append(served, hc.Name)
+			mu.Unlock()
+			return hc.Info.Size() > 0
+		},
+		OnHeaders: func(hc *HookContext) {
+			hc.RequestContext.Response.Header.Set("X-Mount", hc.Mount)
+		},
+		OnNotFound: func(hc *HookContext) bool {
+			if hc.Path != "/custom" {
+				return false
+			}
+			hc.RequestContext.String(consts.StatusNotFound, "custom not found")
+			return true
+		},
+	}))
+
+	tests := []struct {
+		name       string
+		url        string
+		statusCode int
+		body       string
+		mount      string
+	}{
+		{name: "Should serve with headers", url: "/hooks/index.html", statusCode: 200, mount: "/hooks"},
+		{name: "Should serve the rewritten path", url: "/hooks/variant-b", statusCode: 200, mount: "/hooks"},
+		{name: "Should keep rewritten paths in the root", url: "/hooks/escape", statusCode: 404},
+		{name: "Should veto with a 403", url: "/hooks/private/index.html", statusCode: 403},
+		{name: "Should let a veto answer", url: "/hooks/teapot", statusCode: 418},
+		{name: "Should render not found", url: "/hooks/custom", statusCode: 404, body: "custom not found"},
+		{name: "Should fall back to the default not found", url: "/hooks/missing", statusCode: 404, body: "Not Found"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := ut.PerformRequest(h.Engine, consts.MethodGet, tt.url, nil)
+			response := w.Result()
+			assert.DeepEqual(t, tt.statusCode, response.StatusCode())
+			assert.DeepEqual(t, tt.mount, string(response.Header.Peek("X-Mount")))
+			if tt.body != "" {
+				assert.DeepEqual(t, tt.body, string(response.Body()))
+			}
+		})
+	}
+	t.Cleanup(func() {
+		assert.True(t, len(served) == 2)
+	})
+}
@@ -0,0 +1,166 @@
+package filesystem
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"html"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+)
+
+// ConfigInjection adds runtime configuration to the HTML index and
+// fallback pages of a single page application, set with
+// WithConfigInjection, so that one build serves every environment.
+type ConfigInjection struct {
+	// Config is assigned as JSON to window.__CONFIG__, or to the global
+	// of Variable, in a script added at the start of <head>.
+	Config interface{}
+	// Variable is the global the config is assigned to. Defaults to
+	// "__CONFIG__".
+	Variable string
+	// BaseHref sets the <base href> of the page to the path prefix of the
+	// handler, so that the relative URLs of the build resolve under it.
+	BaseHref bool
+}
+
+var (
+	headTag = regexp.MustCompile(`(?i)<head(\s[^>]*)?>`)
+	baseTag = regexp.MustCompile(`(?i)<base\s[^>]*>`)
+
+	jsIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+)
+
+// injectionOption is the ConfigInjection of a handler with the pages it
+// modified.
+type injectionOption struct {
+	// script is the script assigning the config, empty if there is none.
+	script   string
+	baseHref bool
+
+	mu    sync.Mutex
+	pages map[string]injectedPage
+}
+
+// injectedPage is a page modified from a file, valid while the file has
+// the same modification time and size. The <base href> depends on where
+// the handler is mounted, so it is added for each request between head and
+// tail.
+type injectedPage struct {
+	modTime time.Time
+	size    int64
+	head    string
+	tail    string
+	// sum is the hash of the page without its base.
+	sum [sha256.Size]byte
+}
+
+func newInjectionOption(injection ConfigInjection) *injectionOption {
+	o := &injectionOption{baseHref: injection.BaseHref, pages: make(map[string]injectedPage)}
+	if injection.Config != nil {
+		// json.Marshal escapes <, > and &, so the config cannot close the script
+		config, err := json.Marshal(injection.Config)
+		if err != nil {
+			hlog.SystemLogger().Errorf("Failed to marshal the injected config: %s", err)
+			return o
+		}
+		variable := injection.Variable
+		if !jsIdentifier.MatchString(variable) {
+			if variable != "" {
+				hlog.SystemLogger().Errorf("Invalid variable of the injected config %q, using __CONFIG__", variable)
+			}
+			variable = "__CONFIG__"
+		}
+		o.script = "<script>window." + variable + "=" + string(config) + ";</script>"
+	}
+	return o
+}
+
+// applies reports whether the config is injected into the file of res: an
+// HTML index file, the history fallback of WithSPA, or a fixed candidate
+// of the try files chain such as the file of WithNotFoundFile.
+func (i *injectionOption) applies(cfg *option, res resolved) bool {
+	if !isHTML(res.name) {
+		return false
+	}
+	return res.dir || res.fallback || !strings.HasPrefix(res.via, "$uri") || strings.HasSuffix(res.name, cfg.index)
+}
+
+// inject returns the file named name with the config and base of the
+// handler mounted on mount, and its ETag. The file is modified again only
+// if it was modified since.
+func (i *injectionOption) inject(file http.File, name string, stat os.FileInfo, mount string) ([]byte, string, error) {
+	i.mu.Lock()
+	page, ok := i.pages[name]
+	i.mu.Unlock()
+	if !ok || !page.modTime.Equal(stat.ModTime()) || page.size != stat.Size() {
+		src, err := io.ReadAll(file)
+		if err != nil {
+			return nil, "", err
+		}
+		page = i.modify(string(src), stat)
+		i.mu.Lock()
+		i.pages[name] = page
+		i.mu.Unlock()
+	}
+
+	base := ""
+	if i.baseHref {
+		base = `<base href="` + html.EscapeString(trimRight(mount, '/')+"/") + `">`
+	}
+	body := make([]byte, 0, len(page.head)+len(base)+len(page.tail))
+	body = append(append(append(body, page.head...), base...), page.tail...)
+	etag := sha256.New()
+	etag.Write(page.sum[:])
+	etag.Write([]byte(base))
+	return body, `"` + hex.EncodeToString(etag.Sum(nil)[:16]) + `"`, nil
+}
+
+// modify adds the config to doc, the content of a file, and splits it
+// where its base goes.
+func (i *injectionOption) modify(doc string, stat os.FileInfo) injectedPage {
+	// The base replaces the one of the page, or comes first in <head>
+	at := -1
+	if i.baseHref {
+		if loc := baseTag.FindStringIndex(doc); loc != nil {
+			doc, at = doc[:loc[0]]+doc[loc[1]:], loc[0]
+		}
+	}
+	insert := 0
+	if loc := headTag.FindStringIndex(doc); loc != nil {
+		insert = loc[1]
+	}
+	doc = doc[:insert] + i.script + doc[insert:]
+	switch {
+	case at < 0:
+		at = insert
+	case at >= insert:
+		at += len(i.script)
+	}
+	return injectedPage{
+		modTime: stat.ModTime(),
+		size:    stat.Size(),
+		head:    doc[:at],
+		tail:    doc[at:],
+		sum:     sha256.Sum256([]byte(doc)),
+	}
+}
+
+// etagMatches reports whether the If-None-Match header ifNoneMatch
+// matches etag, comparing weakly.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
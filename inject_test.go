@@ -0,0 +1,104 @@
+package filesystem
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/test/assert"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+func TestConfigInjection(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "index.html"), []byte(`<html><HEAD lang="en"><base href="/"><title>app</title></HEAD></html>`), 0o644))
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "404.html"), []byte(`<p>missing</p>`), 0o644))
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "app.js"), []byte(`<head>`), 0o644))
+
+	config := ConfigInjection{Config: map[string]string{"api": "</script>"}, BaseHref: true}
+	h := server.New()
+	NewFSHandler(h, "/app", http.Dir(root), WithSPA("index.html"), WithConfigInjection(config))
+	NewFSHandler(h, "/nf", http.Dir(root), WithNotFoundFile("404.html"), WithConfigInjection(ConfigInjection{Config: 1, Variable: "ENV"}))
+
+	injected := `<html><HEAD lang="en"><script>window.__CONFIG__={"api":"\u003c/script\u003e"};</script><base href="/app/"><title>app</title></HEAD></html>`
+	etag := ut.PerformRequest(h.Engine, consts.MethodGet, "/app/", nil).Result().Header.Peek("ETag")
+	assert.True(t, len(etag) > 2)
+
+	tests := []struct {
+		name       string
+		url        string
+		headers    []ut.Header
+		statusCode int
+		body       string
+		etag       bool
+	}{
+		{name: "Should inject into the index", url: "/app/", statusCode: 200, body: injected, etag: true},
+		{name: "Should inject into the index file", url: "/app/index.html", statusCode: 200, body: injected, etag: true},
+		{name: "Should inject into the fallback", url: "/app/users/1", headers: []ut.Header{{Key: "Accept", Value: "text/html"}}, statusCode: 200, body: injected, etag: true},
+		{name: "Should answer matching ETags with 304", url: "/app/", headers: []ut.Header{{Key: "If-None-Match", Value: string(etag)}}, statusCode: 304, etag: true},
+		{name: "Should ignore Last-Modified", url: "/app/", headers: []ut.Header{{Key: consts.HeaderIfModifiedSince, Value: "Fri, 01 Jan 2100 00:00:00 GMT"}}, statusCode: 200, body: injected, etag: true},
+		{name: "Should not inject into other files", url: "/app/app.js", statusCode: 200, body: "<head>"},
+		{name: "Should inject into the not found file", url: "/nf/missing", statusCode: 200, body: "<script>window.ENV=1;</script><p>missing</p>", etag: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := ut.PerformRequest(h.Engine, consts.MethodGet, tt.url, nil, tt.headers...)
+			response := w.Result()
+			assert.DeepEqual(t, tt.statusCode, response.StatusCode())
+			assert.DeepEqual(t, tt.body, string(response.Body()))
+			assert.DeepEqual(t, tt.etag, len(response.Header.Peek("ETag")) > 0)
+			if tt.etag {
+				assert.DeepEqual(t, "", string(response.Header.Peek(consts.HeaderLastModified)))
+			}
+		})
+	}
+}
+
+func TestEtagMatches(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, etagMatches(`"a", "b"`, `"b"`))
+	assert.True(t, etagMatches(`W/"b"`, `"b"`))
+	assert.True(t, etagMatches(`*`, `"b"`))
+	assert.False(t, etagMatches(``, `"b"`))
+	assert.False(t, etagMatches(`"bb"`, `"b"`))
+}
+
+func TestConfigInjectionVariable(t *testing.T) {
+	t.Parallel()
+
+	assert.DeepEqual(t, "<script>window.__CONFIG__=1;</script>", newInjectionOption(ConfigInjection{Config: 1, Variable: "a;alert(1)"}).script)
+	assert.DeepEqual(t, "", newInjectionOption(ConfigInjection{BaseHref: true}).script)
+}
+
+func TestConfigInjectionMounts(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "index.html"), []byte(`<head><title>app</title></head>`), 0o644))
+
+	cfg := newOption(http.Dir(root), []Option{WithConfigInjection(ConfigInjection{Config: 1, BaseHref: true})})
+	h := server.New()
+	h.GET("/t/:tenant/*filepath", func(ctx context.Context, c *app.RequestContext) {
+		relPath := c.Param("filepath")
+		serve(ctx, c, cfg, routeMount(c, relPath), relPath)
+	})
+
+	etags := make(map[string]bool)
+	for _, tenant := range []string{"a", "b", "a"} {
+		response := ut.PerformRequest(h.Engine, consts.MethodGet, "/t/"+tenant+"/", nil).Result()
+		assert.DeepEqual(t, `<head><base href="/t/`+tenant+`/"><script>window.__CONFIG__=1;</script><title>app</title></head>`, string(response.Body()))
+		etags[string(response.Header.Peek("ETag"))] = true
+	}
+	assert.DeepEqual(t, 2, len(etags))
+	assert.DeepEqual(t, 1, len(cfg.injection.pages))
+}
@@ -0,0 +1,371 @@
+package filesystem
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+const (
+	// liveReloadDebounce is how long changes are collected before they
+	// are sent, so that saving several files reloads the page once.
+	liveReloadDebounce = 100 * time.Millisecond
+	// liveReloadHeartbeat is the interval of the comments sent to idle
+	// clients, so that the streams of gone clients are closed.
+	liveReloadHeartbeat = 15 * time.Second
+)
+
+// LiveReload is a development mode reloading the pages in the browsers
+// when the files of an http.Dir root change, set with WithLiveReload.
+// Changes of stylesheets only swap the stylesheets of the page.
+type LiveReload struct {
+	// Path is the path of the Server-Sent Events endpoint under the prefix
+	// of the handler. Defaults to "/__livereload".
+	Path string
+	// Interval is the polling interval of the root on the platforms
+	// without inotify. Defaults to 500ms. A root live reloaded by several
+	// handlers is polled at the smallest of their intervals.
+	Interval time.Duration
+	// Done stops watching the root once closed, such as the Done channel
+	// of a context cancelled when the handler is replaced or the server
+	// shuts down. Otherwise the root is watched until the handler is
+	// garbage collected, which may never happen.
+	Done <-chan struct{}
+}
+
+var (
+	errLiveReloadRoot = errors.New("live reload needs an http.Dir root")
+
+	closingBody = regexp.MustCompile(`(?i)</body\s*>`)
+)
+
+// liveReloadClient is the script added to the HTML pages, EventSource
+// connecting to the URL it is formatted with.
+const liveReloadClient = `<script>(function(){
+var source=new EventSource(%s);
+source.addEventListener("change",function(e){
+var change=JSON.parse(e.data);
+if(!change.css){location.reload();return}
+var links=document.querySelectorAll('link[rel="stylesheet"]');
+for(var i=0;i<links.length;i++){var u=new URL(links[i].href);u.searchParams.set("livereload",Date.now());links[i].href=u.href}
+});
+})();</script>`
+
+// liveReloadOption is the LiveReload of a handler.
+type liveReloadOption struct {
+	path     string
+	interval time.Duration
+	done     <-chan struct{}
+	hub      *liveReloadHub
+}
+
+// liveReloadHub sends the changes of the root to the clients of a handler.
+// The watcher of the root only references the hub, so that the option is
+// collected with its handler, and then unsubscribed from the watcher.
+type liveReloadHub struct {
+	mu      sync.Mutex
+	clients map[chan []string]struct{}
+	// pending are the paths changed since the last event.
+	pending []string
+	timer   *time.Timer
+}
+
+func newLiveReloadOption(lr LiveReload) *liveReloadOption {
+	p := "/" + strings.Trim(lr.Path, "/")
+	if p == "/" {
+		p = "/__livereload"
+	}
+	interval := lr.Interval
+	if interval <= 0 {
+		interval = 500 * time.Millisecond
+	}
+	return &liveReloadOption{
+		path:     p,
+		interval: interval,
+		done:     lr.Done,
+		hub:      &liveReloadHub{clients: make(map[chan []string]struct{})},
+	}
+}
+
+// watch watches the root, which must be an http.Dir, until done is closed
+// or the option is collected.
+func (l *liveReloadOption) watch(root http.FileSystem) error {
+	dir, ok := root.(http.Dir)
+	if !ok {
+		return errLiveReloadRoot
+	}
+	abs, err := filepath.Abs(string(dir))
+	if err != nil {
+		return err
+	}
+	if err := subscribeDir(abs, l.interval, l.hub); err != nil {
+		return err
+	}
+	// The goroutine must not reference l, which would never be collected
+	collected := make(chan struct{})
+	runtime.SetFinalizer(l, func(*liveReloadOption) { close(collected) })
+	go func(done <-chan struct{}, hub *liveReloadHub) {
+		select {
+		case <-done:
+		case <-collected:
+		}
+		unsubscribeDir(abs, hub)
+	}(l.done, l.hub)
+	return nil
+}
+
+// dirWatcher is the watcher of a directory, shared by the handlers live
+// reloading it.
+type dirWatcher struct {
+	stop func()
+	// interval is the polling interval of the watcher, the smallest one
+	// of the handlers.
+	interval time.Duration
+	hubs     map[*liveReloadHub]struct{}
+}
+
+// dirWatchers are the watchers by directory, so that creating handlers for
+// a directory again, as VirtualHosts.Reload does, does not start watchers
+// again. A watcher is stopped once its last handler is stopped.
+var dirWatchers = struct {
+	sync.Mutex
+	dirs map[string]*dirWatcher
+}{dirs: make(map[string]*dirWatcher)}
+
+// subscribeDir sends the changes of dir to hub, starting the watcher of
+// dir, polling it every interval if needed, unless it is already watched.
+// A watcher polling less often than interval is started again with it.
+func subscribeDir(dir string, interval time.Duration, hub *liveReloadHub) error {
+	dirWatchers.Lock()
+	defer dirWatchers.Unlock()
+	w, ok := dirWatchers.dirs[dir]
+	if !ok || interval < w.interval {
+		if !ok {
+			w = &dirWatcher{hubs: make(map[*liveReloadHub]struct{})}
+		}
+		stop, err := watchDir(dir, interval, w.changed)
+		if err != nil {
+			return err
+		}
+		if ok {
+			w.stop()
+		}
+		w.stop, w.interval = stop, interval
+		dirWatchers.dirs[dir] = w
+	}
+	w.hubs[hub] = struct{}{}
+	return nil
+}
+
+// unsubscribeDir stops sending the changes of dir to hub, stopping the
+// watcher of dir if it was the last hub.
+func unsubscribeDir(dir string, hub *liveReloadHub) {
+	dirWatchers.Lock()
+	defer dirWatchers.Unlock()
+	w, ok := dirWatchers.dirs[dir]
+	if !ok {
+		return
+	}
+	delete(w.hubs, hub)
+	if len(w.hubs) == 0 {
+		delete(dirWatchers.dirs, dir)
+		w.stop()
+	}
+}
+
+// changed sends the change of the file named name to the hubs.
+func (w *dirWatcher) changed(name string) {
+	dirWatchers.Lock()
+	hubs := make([]*liveReloadHub, 0, len(w.hubs))
+	for hub := range w.hubs {
+		hubs = append(hubs, hub)
+	}
+	dirWatchers.Unlock()
+	for _, hub := range hubs {
+		hub.changed(name)
+	}
+}
+
+// changed records that the file named name in the root changed.
+func (h *liveReloadHub) changed(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, p := range h.pending {
+		if p == name {
+			return
+		}
+	}
+	h.pending = append(h.pending, name)
+	if h.timer == nil {
+		h.timer = time.AfterFunc(liveReloadDebounce, h.flush)
+	}
+}
+
+// flush sends the pending changes to the clients.
+func (h *liveReloadHub) flush() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.clients {
+		select {
+		case ch <- h.pending:
+		default:
+		}
+	}
+	h.pending, h.timer = nil, nil
+}
+
+// serveEvents answers the request with the stream of change events.
+func (l *liveReloadOption) serveEvents(c *app.RequestContext) {
+	ch := make(chan []string, 16)
+	l.hub.mu.Lock()
+	l.hub.clients[ch] = struct{}{}
+	l.hub.mu.Unlock()
+
+	c.Response.Header.SetContentType("text/event-stream")
+	c.Response.Header.Set("Cache-Control", "no-store")
+	c.Response.Header.Set("X-Accel-Buffering", "no")
+	c.Response.SetBodyStream(&eventStream{hub: l.hub, ch: ch, buf: []byte("retry: 1000\n\n")}, -1)
+}
+
+// eventStream is the body of the event stream of a client, closed by the
+// server once the client is gone.
+type eventStream struct {
+	hub *liveReloadHub
+	ch  chan []string
+	buf []byte
+	// heartbeat is the timer of the next heartbeat, reset by every read.
+	heartbeat *time.Timer
+}
+
+func (s *eventStream) Read(p []byte) (int, error) {
+	if len(s.buf) == 0 {
+		if s.heartbeat == nil {
+			s.heartbeat = time.NewTimer(liveReloadHeartbeat)
+		} else {
+			s.heartbeat.Reset(liveReloadHeartbeat)
+		}
+		select {
+		case paths := <-s.ch:
+			if !s.heartbeat.Stop() {
+				<-s.heartbeat.C
+			}
+			s.buf = formatChange(paths)
+		case <-s.heartbeat.C:
+			s.buf = []byte(": ping\n\n")
+		}
+	}
+	n := copy(p, s.buf)
+	s.buf = s.buf[n:]
+	return n, nil
+}
+
+func (s *eventStream) Close() error {
+	if s.heartbeat != nil {
+		s.heartbeat.Stop()
+	}
+	s.hub.mu.Lock()
+	delete(s.hub.clients, s.ch)
+	s.hub.mu.Unlock()
+	return nil
+}
+
+// formatChange formats the change event of paths, css being true if they
+// are all stylesheets.
+func formatChange(paths []string) []byte {
+	css := true
+	for _, p := range paths {
+		css = css && strings.EqualFold(filepath.Ext(p), ".css")
+	}
+	data, _ := json.Marshal(struct {
+		Paths []string `json:"paths"`
+		CSS   bool     `json:"css"`
+	}{paths, css})
+	return []byte("event: change\ndata: " + string(data) + "\n\n")
+}
+
+// inject adds the client connecting to the handler mounted on mount to
+// the HTML page body.
+func (l *liveReloadOption) inject(body []byte, mount string) []byte {
+	url, _ := json.Marshal(trimRight(mount, '/') + l.path)
+	client := fmt.Sprintf(liveReloadClient, url)
+	if loc := closingBody.FindAllIndex(body, -1); len(loc) > 0 {
+		last := loc[len(loc)-1][0]
+		return bytes.Join([][]byte{body[:last], []byte(client), body[last:]}, nil)
+	}
+	return append(body[:len(body):len(body)], client...)
+}
+
+// fileState is what pollDir compares to detect the changes of a file.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// pollDir reports the files changed in dir, comparing them every interval
+// until done is closed. It is the watcher of the platforms without inotify.
+func pollDir(dir string, interval time.Duration, changed func(name string), done <-chan struct{}) {
+	prev := snapshotDir(dir)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+		cur := snapshotDir(dir)
+		for _, name := range diffSnapshots(prev, cur) {
+			changed(name)
+		}
+		prev = cur
+	}
+}
+
+// snapshotDir returns the state of the files in dir, by path in the root.
+func snapshotDir(dir string) map[string]fileState {
+	files := make(map[string]fileState)
+	_ = filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			files[rootName(dir, p)] = fileState{modTime: info.ModTime(), size: info.Size()}
+		}
+		return nil
+	})
+	return files
+}
+
+// diffSnapshots returns the files added, modified or removed from prev to
+// cur.
+func diffSnapshots(prev, cur map[string]fileState) []string {
+	var changed []string
+	for name, state := range cur {
+		if old, ok := prev[name]; !ok || !old.modTime.Equal(state.modTime) || old.size != state.size {
+			changed = append(changed, name)
+		}
+	}
+	for name := range prev {
+		if _, ok := cur[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	return changed
+}
+
+// rootName returns the path in the root of the file p of dir.
+func rootName(dir, p string) string {
+	rel, err := filepath.Rel(dir, p)
+	if err != nil {
+		return "/"
+	}
+	return "/" + filepath.ToSlash(rel)
+}
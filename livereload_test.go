@@ -0,0 +1,215 @@
+package filesystem
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/test/assert"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+func TestLiveReload(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "index.html"), []byte("<html><body>hi</BODY></html>"), 0o644))
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "style.css"), []byte("body{}"), 0o644))
+
+	h := server.New()
+	NewFSHandler(h, "/lr", http.Dir(root), WithMaxAge(3600), WithLiveReload(LiveReload{}))
+
+	ims := ut.Header{Key: consts.HeaderIfModifiedSince, Value: time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)}
+	response := ut.PerformRequest(h.Engine, consts.MethodGet, "/lr/", nil, ims).Result()
+	assert.DeepEqual(t, 200, response.StatusCode())
+	assert.DeepEqual(t, "no-store", string(response.Header.Peek("Cache-Control")))
+	body := string(response.Body())
+	assert.True(t, strings.HasPrefix(body, "<html><body>hi<script>"))
+	assert.True(t, strings.HasSuffix(body, "</script></BODY></html>"))
+	assert.True(t, strings.Contains(body, `new EventSource("/lr/__livereload")`))
+
+	response = ut.PerformRequest(h.Engine, consts.MethodGet, "/lr/style.css", nil, ims).Result()
+	assert.DeepEqual(t, 200, response.StatusCode())
+	assert.DeepEqual(t, "no-store", string(response.Header.Peek("Cache-Control")))
+	assert.DeepEqual(t, "body{}", string(response.Body()))
+}
+
+func TestLiveReloadEvents(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	assert.Nil(t, os.MkdirAll(filepath.Join(root, "css"), 0o755))
+	cfg := newOption(http.Dir(root), []Option{WithLiveReload(LiveReload{Path: "events", Interval: 10 * time.Millisecond})})
+	assert.NotNil(t, cfg.liveReload)
+
+	h := server.New()
+	h.GET("/lr/*filepath", func(ctx context.Context, c *app.RequestContext) {
+		serve(ctx, c, cfg, "/lr", c.Param("filepath"))
+	})
+	c := app.NewContext(0)
+	c.Request.SetRequestURI("/lr/events")
+	c.Request.Header.SetMethod(consts.MethodGet)
+	h.Engine.ServeHTTP(context.Background(), c)
+	assert.DeepEqual(t, "text/event-stream", string(c.Response.Header.ContentType()))
+	stream := c.Response.BodyStream()
+
+	read := func() string {
+		chunk := make(chan string, 1)
+		go func() {
+			buf := make([]byte, 512)
+			n, _ := stream.Read(buf)
+			chunk <- string(buf[:n])
+		}()
+		select {
+		case s := <-chunk:
+			return s
+		case <-time.After(5 * time.Second):
+			t.Fatal("no event")
+			return ""
+		}
+	}
+	assert.DeepEqual(t, "retry: 1000\n\n", read())
+
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "css", "site.css"), []byte("a{}"), 0o644))
+	assert.DeepEqual(t, "event: change\ndata: {\"paths\":[\"/css/site.css\"],\"css\":true}\n\n", read())
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "app.js"), []byte("1"), 0o644))
+	assert.DeepEqual(t, "event: change\ndata: {\"paths\":[\"/app.js\"],\"css\":false}\n\n", read())
+
+	assert.Nil(t, stream.(io.Closer).Close())
+	cfg.liveReload.hub.mu.Lock()
+	assert.DeepEqual(t, 0, len(cfg.liveReload.hub.clients))
+	cfg.liveReload.hub.mu.Unlock()
+}
+
+func TestLiveReloadBehindPreHandler(t *testing.T) {
+	t.Parallel()
+
+	var logged []string
+	h := server.New()
+	NewFSHandler(h, "/lr", http.Dir(t.TempDir()),
+		WithLiveReload(LiveReload{}),
+		WithPreHandler(func(context.Context, *app.RequestContext) (func(), bool) { return nil, false }),
+		WithAccessLog(AccessLogSinkFunc(func(entry *AccessLogEntry) {
+			logged = append(logged, entry.URI+" "+strconv.Itoa(entry.Status))
+		})),
+	)
+
+	response := ut.PerformRequest(h.Engine, consts.MethodGet, "/lr/__livereload", nil).Result()
+	assert.DeepEqual(t, 401, response.StatusCode())
+	assert.DeepEqual(t, []string{"/lr/__livereload 401"}, logged)
+}
+
+func TestLiveReloadRoot(t *testing.T) {
+	t.Parallel()
+
+	cfg := newOption(http.FS(os.DirFS(".")), []Option{WithLiveReload(LiveReload{})})
+	assert.Nil(t, cfg.liveReload)
+}
+
+func TestLiveReloadSharesWatchers(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	first, second := &liveReloadHub{clients: make(map[chan []string]struct{})}, &liveReloadHub{clients: make(map[chan []string]struct{})}
+	assert.Nil(t, subscribeDir(root, 10*time.Millisecond, first))
+	assert.Nil(t, subscribeDir(root, 10*time.Millisecond, second))
+	dirWatchers.Lock()
+	w := dirWatchers.dirs[root]
+	assert.DeepEqual(t, 2, len(w.hubs))
+	dirWatchers.Unlock()
+
+	unsubscribeDir(root, first)
+	dirWatchers.Lock()
+	assert.True(t, dirWatchers.dirs[root] == w)
+	dirWatchers.Unlock()
+
+	ch := make(chan []string, 1)
+	second.mu.Lock()
+	second.clients[ch] = struct{}{}
+	second.mu.Unlock()
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "a.txt"), []byte("a"), 0o644))
+	select {
+	case paths := <-ch:
+		assert.DeepEqual(t, []string{"/a.txt"}, paths)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change")
+	}
+
+	unsubscribeDir(root, second)
+	dirWatchers.Lock()
+	_, ok := dirWatchers.dirs[root]
+	dirWatchers.Unlock()
+	assert.False(t, ok)
+}
+
+func TestLiveReloadDone(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	done := make(chan struct{})
+	cfg := newOption(http.Dir(root), []Option{WithLiveReload(LiveReload{Done: done})})
+	assert.NotNil(t, cfg.liveReload)
+	watched := func() bool {
+		dirWatchers.Lock()
+		defer dirWatchers.Unlock()
+		_, ok := dirWatchers.dirs[root]
+		return ok
+	}
+	assert.True(t, watched())
+
+	close(done)
+	for deadline := time.Now().Add(5 * time.Second); watched(); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("the watcher was not stopped")
+		}
+	}
+}
+
+func TestLiveReloadSmallestInterval(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	first, second := &liveReloadHub{clients: make(map[chan []string]struct{})}, &liveReloadHub{clients: make(map[chan []string]struct{})}
+	assert.Nil(t, subscribeDir(root, time.Second, first))
+	assert.Nil(t, subscribeDir(root, 10*time.Millisecond, second))
+	assert.Nil(t, subscribeDir(root, time.Minute, second))
+	dirWatchers.Lock()
+	assert.DeepEqual(t, 10*time.Millisecond, dirWatchers.dirs[root].interval)
+	dirWatchers.Unlock()
+
+	ch := make(chan []string, 1)
+	first.mu.Lock()
+	first.clients[ch] = struct{}{}
+	first.mu.Unlock()
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "a.txt"), []byte("a"), 0o644))
+	select {
+	case paths := <-ch:
+		assert.DeepEqual(t, []string{"/a.txt"}, paths)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change")
+	}
+	unsubscribeDir(root, first)
+	unsubscribeDir(root, second)
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	prev := map[string]fileState{"/a": {modTime: now, size: 1}, "/b": {modTime: now, size: 1}, "/c": {modTime: now, size: 1}}
+	cur := map[string]fileState{"/a": {modTime: now, size: 1}, "/b": {modTime: now.Add(time.Second), size: 1}, "/d": {modTime: now, size: 1}}
+	changed := diffSnapshots(prev, cur)
+	assert.DeepEqual(t, 3, len(changed))
+	for _, name := range []string{"/b", "/c", "/d"} {
+		assert.True(t, strings.Contains(strings.Join(changed, " "), name))
+	}
+}
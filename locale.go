@@ -0,0 +1,129 @@
+package filesystem
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// negotiateLanguages returns the available languages in the order the
+// request prefers them, ending with the default language.
+//
+// A language forced by the query parameter or the cookie of
+// WithLanguageOverride comes first, then the languages of Accept-Language.
+// A request under the directory of a language, with
+// WithLanguageDirectories, is not negotiated and gets no languages.
+func (o *option) negotiateLanguages(c *app.RequestContext, urlPath string) []string {
+	if len(o.languages) == 0 {
+		return nil
+	}
+	if o.languageDirs {
+		first, _, _ := strings.Cut(strings.TrimPrefix(urlPath, "/"), "/")
+		if _, ok := o.availableLanguage(first); ok {
+			return nil
+		}
+	}
+
+	var langs []string
+	add := func(lang string) {
+		for _, l := range langs {
+			if l == lang {
+				return
+			}
+		}
+		langs = append(langs, lang)
+	}
+	if o.languageQuery != "" {
+		if lang, ok := o.availableLanguage(c.Query(o.languageQuery)); ok {
+			add(lang)
+		}
+	}
+	if o.languageCookie != "" {
+		if lang, ok := o.availableLanguage(string(c.Cookie(o.languageCookie))); ok {
+			add(lang)
+		}
+	}
+	for _, tag := range parseAcceptLanguage(string(c.Request.Header.Peek("Accept-Language"))) {
+		if lang, ok := o.availableLanguage(tag); ok {
+			add(lang)
+			continue
+		}
+		// Fall back to a variant of the same primary language, so that
+		// "de-AT" gets "de" and "zh" gets "zh-CN".
+		primary, _, _ := strings.Cut(tag, "-")
+		for _, lang := range o.languages {
+			if p, _, _ := strings.Cut(lang, "-"); strings.EqualFold(p, primary) {
+				add(lang)
+				break
+			}
+		}
+	}
+	add(o.defaultLanguage)
+	return langs
+}
+
+// availableLanguage returns the configured spelling of lang if it is one
+// of the available languages.
+func (o *option) availableLanguage(lang string) (string, bool) {
+	if lang == "" {
+		return "", false
+	}
+	for _, l := range o.languages {
+		if strings.EqualFold(l, lang) {
+			return l, true
+		}
+	}
+	return "", false
+}
+
+// localize returns the path of the variant of name in lang: index.html
+// becomes index.de.html, or /de/index.html with WithLanguageDirectories.
+func (o *option) localize(name, lang string) string {
+	if o.languageDirs {
+		return o.pathPrefix + "/" + lang + strings.TrimPrefix(name, o.pathPrefix)
+	}
+	dir, file := path.Split(name)
+	ext := path.Ext(file)
+	return dir + strings.TrimSuffix(file, ext) + "." + lang + ext
+}
+
+// openLocalized opens the first variant of name in langs that is a regular
+// file, or name itself, and returns the language of the file opened, empty
+// for name itself.
+func (o *option) openLocalized(name string, langs []string) (http.File, string, string, error) {
+	if len(name) > 1 {
+		for _, lang := range langs {
+			variant := o.localize(name, lang)
+			f, err := o.open(variant)
+			if err != nil {
+				continue
+			}
+			if stat, err := f.Stat(); err == nil && !stat.IsDir() {
+				return f, variant, lang, nil
+			}
+			_ = f.Close()
+		}
+	}
+	f, err := o.open(name)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return f, name, "", nil
+}
+
+// setLanguageHeaders sets the Content-Language of a localized variant, and
+// tells caches the response depends on the negotiated language.
+func setLanguageHeaders(c *app.RequestContext, cfg *option, res resolved) {
+	if len(cfg.languages) == 0 {
+		return
+	}
+	if res.lang != "" {
+		c.Response.Header.Set("Content-Language", res.lang)
+	}
+	c.Response.Header.Add("Vary", "Accept-Language")
+	if cfg.languageCookie != "" {
+		c.Response.Header.Add("Vary", "Cookie")
+	}
+}
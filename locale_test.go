@@ -0,0 +1,93 @@
+package filesystem
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/test/assert"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+func TestLanguages(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	files := map[string]string{
+		"suffix/index.en.html":    "en",
+		"suffix/index.de.html":    "de",
+		"suffix/index.zh-CN.html": "zh",
+		"suffix/about.html":       "about",
+		"suffix/about.de.html":    "über",
+		"dirs/index.html":         "en",
+		"dirs/de/index.html":      "de",
+		"dirs/de/only.html":       "nur",
+	}
+	for name, content := range files {
+		name = filepath.Join(root, filepath.FromSlash(name))
+		assert.Nil(t, os.MkdirAll(filepath.Dir(name), 0o755))
+		assert.Nil(t, os.WriteFile(name, []byte(content), 0o644))
+	}
+
+	h := server.New()
+	NewFSHandler(h, "/suffix", http.Dir(filepath.Join(root, "suffix")),
+		WithLanguages("en", "de", "zh-CN"),
+		WithLanguageOverride("lang", "lang"),
+	)
+	NewFSHandler(h, "/dirs", http.Dir(filepath.Join(root, "dirs")),
+		WithLanguages("en", "de"),
+		WithLanguageDirectories(true),
+	)
+
+	tests := []struct {
+		name           string
+		url            string
+		headers        []ut.Header
+		statusCode     int
+		body           string
+		contentLang    string
+		vary           string
+		acceptLanguage string
+	}{
+		{name: "Should serve the default language", url: "/suffix/", statusCode: 200, body: "en", contentLang: "en", vary: "Accept-Language"},
+		{name: "Should negotiate the preferred language", url: "/suffix/", acceptLanguage: "fr, de;q=0.8, en;q=0.5", statusCode: 200, body: "de", contentLang: "de"},
+		{name: "Should match the primary language", url: "/suffix/", acceptLanguage: "zh", statusCode: 200, body: "zh", contentLang: "zh-CN"},
+		{name: "Should match case-insensitively", url: "/suffix/", acceptLanguage: "ZH-cn", statusCode: 200, body: "zh", contentLang: "zh-CN"},
+		{name: "Should prefer the query override", url: "/suffix/?lang=de", acceptLanguage: "en", statusCode: 200, body: "de", contentLang: "de"},
+		{name: "Should prefer the cookie override", url: "/suffix/", acceptLanguage: "en", headers: []ut.Header{{Key: "Cookie", Value: "lang=zh-CN"}}, statusCode: 200, body: "zh", contentLang: "zh-CN"},
+		{name: "Should ignore an unknown override", url: "/suffix/?lang=xx", acceptLanguage: "de", statusCode: 200, body: "de", contentLang: "de"},
+		{name: "Should fall back to the file itself", url: "/suffix/about.html", acceptLanguage: "zh-CN", statusCode: 200, body: "about"},
+		{name: "Should serve the variant of a file", url: "/suffix/about.html", acceptLanguage: "de-AT", statusCode: 200, body: "über", contentLang: "de"},
+		{name: "Should serve a language directory", url: "/dirs/", acceptLanguage: "de", statusCode: 200, body: "de", contentLang: "de"},
+		{name: "Should fall back outside language directories", url: "/dirs/", acceptLanguage: "fr", statusCode: 200, body: "en"},
+		{name: "Should serve language directories as is", url: "/dirs/de/only.html", acceptLanguage: "en", statusCode: 200, body: "nur"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			headers := tt.headers
+			if tt.acceptLanguage != "" {
+				headers = append(headers, ut.Header{Key: "Accept-Language", Value: tt.acceptLanguage})
+			}
+			w := ut.PerformRequest(h.Engine, consts.MethodGet, tt.url, nil, headers...)
+			response := w.Result()
+			assert.DeepEqual(t, tt.statusCode, response.StatusCode())
+			assert.DeepEqual(t, tt.body, string(response.Body()))
+			assert.DeepEqual(t, tt.contentLang, string(response.Header.Peek("Content-Language")))
+			if tt.vary != "" {
+				assert.DeepEqual(t, tt.vary, string(response.Header.Peek("Vary")))
+			}
+		})
+	}
+}
+
+func TestNegotiateLanguagesWithoutLanguages(t *testing.T) {
+	t.Parallel()
+
+	cfg := newOption(http.Dir(t.TempDir()), nil)
+	assert.Nil(t, cfg.negotiateLanguages(nil, "/"))
+}
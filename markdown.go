@@ -0,0 +1,150 @@
+package filesystem
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// Markdown renders the Markdown files (.md) as HTML pages, set with
+// WithMarkdown. The source of a file is served with ?raw=1.
+type Markdown struct {
+	// Layout is executed with the MarkdownPage of each file. Defaults to
+	// DefaultMarkdownLayout.
+	Layout *template.Template
+	// DirectoryIndex serves the README.md of the directories without index
+	// file.
+	DirectoryIndex bool
+}
+
+// MarkdownPage is the data the Layout of Markdown is executed with.
+type MarkdownPage struct {
+	// Title is the text of the first heading of level 1, or else the name
+	// of the file.
+	Title string
+	// Name is the path of the file in the root.
+	Name    string
+	ModTime time.Time
+	// Content is the document rendered as HTML.
+	Content template.HTML
+	// TOC is the table of contents, the headings of the document in order.
+	TOC []MarkdownHeading
+}
+
+// MarkdownHeading is a heading of a Markdown document.
+type MarkdownHeading struct {
+	Level int
+	// ID is the anchor of the heading, unique in the document.
+	ID   string
+	Text string
+}
+
+// DefaultMarkdownLayout is the layout of Markdown without Layout: the
+// document next to its table of contents.
+var DefaultMarkdownLayout = template.Must(template.New("markdown").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body{margin:0;font:16px/1.6 -apple-system,"Segoe UI",Helvetica,Arial,sans-serif;color:#1f2328;display:flex}
+nav{flex:0 0 16rem;padding:2rem 1rem;border-right:1px solid #d0d7de;font-size:14px}
+nav ul{list-style:none;margin:0;padding:0}
+nav a{color:inherit;text-decoration:none}
+main{flex:1;max-width:52rem;padding:2rem}
+.toc-3{padding-left:1rem}.toc-4,.toc-5,.toc-6{padding-left:2rem}
+.anchor{visibility:hidden;text-decoration:none;color:#8c959f}
+h1:hover .anchor,h2:hover .anchor,h3:hover .anchor,h4:hover .anchor,h5:hover .anchor,h6:hover .anchor{visibility:visible}
+pre{background:#f6f8fa;padding:1rem;overflow:auto;border-radius:6px}
+code{font-family:ui-monospace,SFMono-Regular,Menlo,monospace;font-size:85%}
+blockquote{margin:0;padding:0 1rem;color:#59636e;border-left:.25rem solid #d0d7de}
+table{border-collapse:collapse}th,td{border:1px solid #d0d7de;padding:.25rem .75rem}
+.hl-keyword{color:#cf222e}.hl-string{color:#0a3069}.hl-comment{color:#6e7781;font-style:italic}.hl-number{color:#0550ae}
+</style>
+</head>
+<body>
+{{- with .TOC}}
+<nav><ul>
+{{- range .}}{{if gt .Level 1}}
+<li class="toc-{{.Level}}"><a href="#{{.ID}}">{{.Text}}</a></li>
+{{- end}}{{end}}
+</ul></nav>
+{{- end}}
+<main>
+{{.Content}}</main>
+</body>
+</html>
+`))
+
+// markdownOption is the Markdown of a handler with the pages it rendered.
+type markdownOption struct {
+	Markdown
+
+	mu    sync.Mutex
+	pages map[string]renderedPage
+}
+
+// renderedPage is a page rendered from a file, valid while the file has
+// the same modification time and size.
+type renderedPage struct {
+	modTime time.Time
+	size    int64
+	body    []byte
+}
+
+// renders reports whether the file named name is rendered for the request.
+func (m *markdownOption) renders(c *app.RequestContext, name string) bool {
+	return strings.EqualFold(path.Ext(name), ".md") && c.Query("raw") != "1"
+}
+
+// render returns the page of the Markdown file named name, rendering it
+// only if it was modified since it was last rendered.
+func (m *markdownOption) render(file http.File, name string, stat os.FileInfo) ([]byte, error) {
+	m.mu.Lock()
+	cached, ok := m.pages[name]
+	m.mu.Unlock()
+	if ok && cached.modTime.Equal(stat.ModTime()) && cached.size == stat.Size() {
+		return cached.body, nil
+	}
+
+	src, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	content, toc := renderMarkdown(src)
+	page := MarkdownPage{
+		Title:   strings.TrimSuffix(path.Base(name), path.Ext(name)),
+		Name:    name,
+		ModTime: stat.ModTime(),
+		Content: template.HTML(content),
+		TOC:     toc,
+	}
+	for _, heading := range toc {
+		if heading.Level == 1 {
+			page.Title = heading.Text
+			break
+		}
+	}
+	layout := m.Layout
+	if layout == nil {
+		layout = DefaultMarkdownLayout
+	}
+	var buf bytes.Buffer
+	if err := layout.Execute(&buf, page); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	m.pages[name] = renderedPage{modTime: stat.ModTime(), size: stat.Size(), body: buf.Bytes()}
+	m.mu.Unlock()
+	return buf.Bytes(), nil
+}
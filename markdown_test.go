@@ -0,0 +1,102 @@
+package filesystem
+
+import (
+	"html/template"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/test/assert"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+func TestMarkdown(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	assert.Nil(t, os.MkdirAll(filepath.Join(root, "docs", "empty"), 0o755))
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "docs", "README.md"), []byte("# Docs\n\n## Setup\n\nRun `make`."), 0o644))
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "guide.md"), []byte("Intro\n\n## Usage"), 0o644))
+
+	h := server.New()
+	NewFSHandler(h, "/md", http.Dir(root), WithMarkdown(Markdown{DirectoryIndex: true}))
+	NewFSHandler(h, "/layout", http.Dir(root), WithMarkdown(Markdown{
+		Layout: template.Must(template.New("").Parse("{{.Title}}|{{range .TOC}}{{.ID}} {{end}}|{{.Content}}")),
+	}))
+
+	tests := []struct {
+		name        string
+		method      string
+		url         string
+		statusCode  int
+		contentType string
+		contains    []string
+	}{
+		{name: "Should render with the default layout", url: "/md/guide.md", statusCode: 200, contentType: "text/html; charset=utf-8", contains: []string{
+			"<title>guide</title>", `<li class="toc-2"><a href="#usage">Usage</a></li>`, "<p>Intro</p>",
+		}},
+		{name: "Should serve the source", url: "/md/guide.md?raw=1", statusCode: 200, contentType: "text/markdown", contains: []string{"Intro\n\n## Usage"}},
+		{name: "Should serve README.md as index", url: "/md/docs/", statusCode: 200, contentType: "text/html; charset=utf-8", contains: []string{
+			"<title>Docs</title>", "<code>make</code>",
+		}},
+		{name: "Should not index directories without README.md", url: "/md/docs/empty/", statusCode: 403},
+		{name: "Should render HEAD requests", method: consts.MethodHead, url: "/md/guide.md", statusCode: 200, contentType: "text/html; charset=utf-8"},
+		{name: "Should use the layout", url: "/layout/docs/README.md", statusCode: 200, contains: []string{
+			"Docs|docs setup |<h1 id=\"docs\">",
+		}},
+		{name: "Should not index without DirectoryIndex", url: "/layout/docs/", statusCode: 403},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			method := tt.method
+			if method == "" {
+				method = consts.MethodGet
+			}
+			w := ut.PerformRequest(h.Engine, method, tt.url, nil)
+			response := w.Result()
+			assert.DeepEqual(t, tt.statusCode, response.StatusCode())
+			if tt.contentType != "" {
+				assert.DeepEqual(t, tt.contentType, string(response.Header.ContentType()))
+			}
+			for _, s := range tt.contains {
+				assert.Assert(t, strings.Contains(string(response.Body()), s), s)
+			}
+		})
+	}
+}
+
+func TestMarkdownCache(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	name := filepath.Join(root, "page.md")
+	assert.Nil(t, os.WriteFile(name, []byte("first"), 0o644))
+
+	h := server.New()
+	NewFSHandler(h, "/md", http.Dir(root), WithMarkdown(Markdown{
+		Layout: template.Must(template.New("").Parse("{{.Content}}")),
+	}))
+	get := func() string {
+		return string(ut.PerformRequest(h.Engine, consts.MethodGet, "/md/page.md", nil).Result().Body())
+	}
+	assert.DeepEqual(t, "<p>first</p>\n", get())
+
+	// Same size and modification time: the cached page is served
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	assert.Nil(t, os.WriteFile(name, []byte("stale"), 0o644))
+	assert.Nil(t, os.Chtimes(name, modTime, modTime))
+	assert.DeepEqual(t, "<p>stale</p>\n", get())
+	assert.Nil(t, os.WriteFile(name, []byte("fresh"), 0o644))
+	assert.Nil(t, os.Chtimes(name, modTime, modTime))
+	assert.DeepEqual(t, "<p>stale</p>\n", get())
+
+	assert.Nil(t, os.Chtimes(name, modTime.Add(time.Minute), modTime.Add(time.Minute)))
+	assert.DeepEqual(t, "<p>fresh</p>\n", get())
+}
@@ -0,0 +1,826 @@
+package filesystem
+
+import (
+	"html"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// markdownRenderer renders Markdown documents to HTML. It supports the
+// CommonMark blocks and inlines documentation uses, and the tables, task
+// lists and strikethrough of GitHub. Raw HTML is escaped, and links can
+// only use the http, https and mailto schemes.
+type markdownRenderer struct {
+	headings []MarkdownHeading
+	// ids counts the uses of the heading anchors, to number duplicates.
+	ids map[string]int
+	// tight renders paragraphs without <p>, in the items of tight lists.
+	tight bool
+	// depth is the number of block quotes and lists the blocks are in.
+	depth int
+}
+
+// markdownMaxNesting is the depth of the block quotes and lists, and of
+// the links and emphasis within a paragraph, rendered as such. Deeper
+// markup is rendered as text, so that rendering stays linear in the size
+// of the document.
+const markdownMaxNesting = 32
+
+// renderMarkdown renders src, returning the HTML and the headings.
+func renderMarkdown(src []byte) (string, []MarkdownHeading) {
+	r := &markdownRenderer{ids: make(map[string]int)}
+	text := strings.ReplaceAll(string(src), "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\t", "    ")
+	var b strings.Builder
+	r.blocks(&b, strings.Split(text, "\n"))
+	return b.String(), r.headings
+}
+
+func (r *markdownRenderer) blocks(b *strings.Builder, lines []string) {
+	var para []string
+	flush := func() {
+		if len(para) == 0 {
+			return
+		}
+		content := r.inline(strings.TrimRight(strings.Join(para, "\n"), " "))
+		if r.tight {
+			b.WriteString(content)
+			b.WriteByte('\n')
+		} else {
+			b.WriteString("<p>")
+			b.WriteString(content)
+			b.WriteString("</p>\n")
+		}
+		para = nil
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		indent := leadingSpaces(line)
+		if trimmed == "" {
+			flush()
+			continue
+		}
+		if indent >= 4 && len(para) == 0 {
+			var code []string
+			for ; i < len(lines); i++ {
+				if strings.TrimSpace(lines[i]) != "" && leadingSpaces(lines[i]) < 4 {
+					break
+				}
+				code = append(code, cutIndent(lines[i], 4))
+			}
+			i--
+			for len(code) > 0 && strings.TrimSpace(code[len(code)-1]) == "" {
+				code = code[:len(code)-1]
+			}
+			r.code(b, "", code)
+			continue
+		}
+		if fence := codeFence(trimmed); fence != "" {
+			flush()
+			info := strings.TrimSpace(strings.TrimLeft(trimmed, fence[:1]))
+			var code []string
+			for i++; i < len(lines); i++ {
+				if t := strings.TrimSpace(lines[i]); strings.HasPrefix(t, fence) && strings.Trim(t, fence[:1]) == "" {
+					break
+				}
+				code = append(code, cutIndent(lines[i], indent))
+			}
+			lang, _, _ := strings.Cut(info, " ")
+			r.code(b, lang, code)
+			continue
+		}
+		if level, text, ok := atxHeading(trimmed); ok {
+			flush()
+			r.heading(b, level, text)
+			continue
+		}
+		if len(para) > 0 && indent < 4 && (strings.Trim(trimmed, "=") == "" || strings.Trim(trimmed, "-") == "") {
+			level := 1
+			if trimmed[0] == '-' {
+				level = 2
+			}
+			text := strings.Join(para, "\n")
+			para = nil
+			r.heading(b, level, text)
+			continue
+		}
+		if isThematicBreak(trimmed) {
+			flush()
+			b.WriteString("<hr>\n")
+			continue
+		}
+		if strings.HasPrefix(trimmed, ">") && r.depth < markdownMaxNesting {
+			flush()
+			var quoted []string
+			for ; i < len(lines); i++ {
+				t := strings.TrimSpace(lines[i])
+				if !strings.HasPrefix(t, ">") {
+					break
+				}
+				quoted = append(quoted, strings.TrimPrefix(t[1:], " "))
+			}
+			i--
+			tight := r.tight
+			r.tight = false
+			b.WriteString("<blockquote>\n")
+			r.depth++
+			r.blocks(b, quoted)
+			r.depth--
+			b.WriteString("</blockquote>\n")
+			r.tight = tight
+			continue
+		}
+		if _, ok := parseListMarker(line); ok && r.depth < markdownMaxNesting {
+			flush()
+			i = r.list(b, lines, i) - 1
+			continue
+		}
+		if i+1 < len(lines) && strings.Contains(trimmed, "|") && isTableDelimiter(lines[i+1]) {
+			flush()
+			i = r.table(b, lines, i) - 1
+			continue
+		}
+		para = append(para, strings.TrimLeft(line, " "))
+	}
+	flush()
+}
+
+// code writes a code block, highlighted for lang.
+func (r *markdownRenderer) code(b *strings.Builder, lang string, lines []string) {
+	b.WriteString("<pre><code")
+	if lang != "" {
+		b.WriteString(` class="language-`)
+		b.WriteString(html.EscapeString(lang))
+		b.WriteByte('"')
+	}
+	b.WriteByte('>')
+	if len(lines) > 0 {
+		b.WriteString(highlightCode(lang, strings.Join(lines, "\n")+"\n"))
+	}
+	b.WriteString("</code></pre>\n")
+}
+
+// heading writes a heading with an anchor, and adds it to the headings.
+func (r *markdownRenderer) heading(b *strings.Builder, level int, text string) {
+	content := r.inline(text)
+	plain := html.UnescapeString(stripTags(content))
+	id := r.anchor(plain)
+	r.headings = append(r.headings, MarkdownHeading{Level: level, ID: id, Text: plain})
+
+	tag := "h" + strconv.Itoa(level)
+	b.WriteString("<" + tag + ` id="` + html.EscapeString(id) + `">`)
+	b.WriteString(content)
+	b.WriteString(` <a class="anchor" href="#` + html.EscapeString(id) + `" aria-hidden="true">#</a>`)
+	b.WriteString("</" + tag + ">\n")
+}
+
+// anchor returns a unique anchor for a heading: its lower cased letters
+// and digits, words joined by hyphens as GitHub does.
+func (r *markdownRenderer) anchor(text string) string {
+	var b strings.Builder
+	for _, ch := range strings.ToLower(strings.TrimSpace(text)) {
+		switch {
+		case unicode.IsLetter(ch) || unicode.IsDigit(ch) || ch == '_' || ch == '-':
+			b.WriteRune(ch)
+		case ch == ' ':
+			b.WriteByte('-')
+		}
+	}
+	id := b.String()
+	if id == "" {
+		id = "section"
+	}
+	n := r.ids[id]
+	r.ids[id] = n + 1
+	if n > 0 {
+		id += "-" + strconv.Itoa(n)
+	}
+	return id
+}
+
+// listMarker is the marker starting a list item.
+type listMarker struct {
+	ordered bool
+	// delim is the bullet, or the delimiter following the number.
+	delim byte
+	start int
+	// width is the indentation of the content of the item.
+	width int
+	// content is the rest of the line after the marker.
+	content string
+}
+
+func parseListMarker(line string) (listMarker, bool) {
+	indent := leadingSpaces(line)
+	if indent >= 4 {
+		return listMarker{}, false
+	}
+	rest := line[indent:]
+	m := listMarker{}
+	n := 0
+	switch {
+	case rest != "" && (rest[0] == '-' || rest[0] == '*' || rest[0] == '+'):
+		m.delim, n = rest[0], 1
+	default:
+		for n < len(rest) && n < 9 && isDigit(rest[n]) {
+			n++
+		}
+		if n == 0 || n >= len(rest) || (rest[n] != '.' && rest[n] != ')') {
+			return listMarker{}, false
+		}
+		m.ordered, m.delim = true, rest[n]
+		m.start, _ = strconv.Atoi(rest[:n])
+		n++
+	}
+	if n < len(rest) && rest[n] != ' ' {
+		return listMarker{}, false
+	}
+	spaces := leadingSpaces(rest[n:])
+	if spaces == 0 || spaces > 4 || n+spaces == len(rest) {
+		spaces = 1
+	}
+	m.width = indent + n + spaces
+	if m.width < len(line) {
+		m.content = line[m.width:]
+	}
+	return m, true
+}
+
+// list writes the list starting at lines[i] and returns the index of the
+// line following it.
+func (r *markdownRenderer) list(b *strings.Builder, lines []string, i int) int {
+	first, _ := parseListMarker(lines[i])
+	sibling := func(line string) (listMarker, bool) {
+		m, ok := parseListMarker(line)
+		return m, ok && m.ordered == first.ordered && m.delim == first.delim && leadingSpaces(line) < first.width
+	}
+
+	var items [][]string
+	width, loose := 0, false
+	for i < len(lines) {
+		line := lines[i]
+		if m, ok := sibling(line); ok {
+			items = append(items, []string{m.content})
+			width = m.width
+			i++
+			continue
+		}
+		item := items[len(items)-1]
+		if strings.TrimSpace(line) == "" {
+			j := i + 1
+			for j < len(lines) && strings.TrimSpace(lines[j]) == "" {
+				j++
+			}
+			if j == len(lines) {
+				break
+			}
+			if _, ok := sibling(lines[j]); !ok && leadingSpaces(lines[j]) < width {
+				break
+			}
+			// The blank lines up to j all belong to the item
+			for ; i < j; i++ {
+				item = append(item, "")
+			}
+			items[len(items)-1] = item
+			loose = true
+			continue
+		}
+		if leadingSpaces(line) >= width {
+			items[len(items)-1] = append(item, cutIndent(line, width))
+			i++
+			continue
+		}
+		// A lazy continuation of the paragraph of the item
+		if _, ok := parseListMarker(line); !ok && strings.TrimSpace(item[len(item)-1]) != "" && !startsBlock(line) {
+			items[len(items)-1] = append(item, strings.TrimSpace(line))
+			i++
+			continue
+		}
+		break
+	}
+
+	tag := "ul"
+	if first.ordered {
+		tag = "ol"
+	}
+	b.WriteString("<" + tag)
+	if first.ordered && first.start != 1 {
+		b.WriteString(` start="` + strconv.Itoa(first.start) + `"`)
+	}
+	b.WriteString(">\n")
+	tight := r.tight
+	r.tight = !loose
+	r.depth++
+	for _, item := range items {
+		for len(item) > 0 && strings.TrimSpace(item[len(item)-1]) == "" {
+			item = item[:len(item)-1]
+		}
+		b.WriteString("<li>")
+		if len(item) > 0 {
+			if checked, rest, ok := taskMarker(item[0]); ok {
+				b.WriteString(`<input type="checkbox" disabled`)
+				if checked {
+					b.WriteString(" checked")
+				}
+				b.WriteString("> ")
+				item[0] = rest
+			}
+		}
+		var ib strings.Builder
+		r.blocks(&ib, item)
+		b.WriteString(strings.TrimSuffix(ib.String(), "\n"))
+		b.WriteString("</li>\n")
+	}
+	r.depth--
+	r.tight = tight
+	b.WriteString("</" + tag + ">\n")
+	return i
+}
+
+// taskMarker parses the "[ ] " or "[x] " starting a task list item.
+func taskMarker(s string) (checked bool, rest string, ok bool) {
+	if len(s) < 4 || s[0] != '[' || s[2] != ']' || s[3] != ' ' {
+		return false, s, false
+	}
+	switch s[1] {
+	case ' ':
+		return false, s[4:], true
+	case 'x', 'X':
+		return true, s[4:], true
+	}
+	return false, s, false
+}
+
+// table writes the table starting at lines[i] and returns the index of
+// the line following it.
+func (r *markdownRenderer) table(b *strings.Builder, lines []string, i int) int {
+	header := splitTableRow(lines[i])
+	var aligns []string
+	for _, cell := range splitTableRow(lines[i+1]) {
+		cell = strings.TrimSpace(cell)
+		left, right := strings.HasPrefix(cell, ":"), strings.HasSuffix(cell, ":")
+		switch {
+		case left && right:
+			aligns = append(aligns, "center")
+		case right:
+			aligns = append(aligns, "right")
+		case left:
+			aligns = append(aligns, "left")
+		default:
+			aligns = append(aligns, "")
+		}
+	}
+	row := func(cells []string, tag string) {
+		b.WriteString("<tr>")
+		for j := range header {
+			b.WriteString("<" + tag)
+			if j < len(aligns) && aligns[j] != "" {
+				b.WriteString(` style="text-align:` + aligns[j] + `"`)
+			}
+			b.WriteByte('>')
+			if j < len(cells) {
+				b.WriteString(r.inline(strings.TrimSpace(cells[j])))
+			}
+			b.WriteString("</" + tag + ">")
+		}
+		b.WriteString("</tr>\n")
+	}
+
+	b.WriteString("<table>\n<thead>\n")
+	row(header, "th")
+	b.WriteString("</thead>\n<tbody>\n")
+	for i += 2; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "" || !strings.Contains(lines[i], "|") || startsBlock(lines[i]) {
+			break
+		}
+		row(splitTableRow(lines[i]), "td")
+	}
+	b.WriteString("</tbody>\n</table>\n")
+	return i
+}
+
+// splitTableRow returns the cells of a table row, "\|" escaping a pipe.
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, cell.String())
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+	return append(cells, cell.String())
+}
+
+func isTableDelimiter(line string) bool {
+	cells := splitTableRow(line)
+	for _, cell := range cells {
+		cell = strings.TrimSpace(cell)
+		cell = strings.TrimSuffix(strings.TrimPrefix(cell, ":"), ":")
+		if cell == "" || strings.Trim(cell, "-") != "" {
+			return false
+		}
+	}
+	return strings.Contains(line, "-")
+}
+
+// inline renders the inlines of s: code spans, links, images, autolinks,
+// emphasis, strikethrough and hard line breaks.
+func (r *markdownRenderer) inline(s string) string {
+	var b strings.Builder
+	p := &inlineParser{src: s, closers: make(map[string]*closerScan)}
+	p.render(&b, 0, len(s), 0)
+	return b.String()
+}
+
+// inlineParser renders the inlines of src. The inlines are parsed from left
+// to right, nested ones included, so the delimiters closing emphasis and
+// code spans are each searched for in a single pass over src, and the
+// brackets and parentheses of links are matched once.
+type inlineParser struct {
+	src string
+	// closers are the searches for the delimiters closing emphasis, by
+	// delimiter.
+	closers map[string]*closerScan
+	// brackets and parens map the index of an opening bracket or
+	// parenthesis to the index of the one closing it.
+	brackets, parens map[int]int
+	// backticks are the indexes of the runs of backticks, by length, from
+	// the first one following the last code span.
+	backticks map[int][]int
+}
+
+// closerScan is the search for the delimiter closing emphasis.
+type closerScan struct {
+	// found is the index of the last delimiter found, -1 if there is none
+	// from scanned on.
+	found   int
+	scanned int
+}
+
+// render writes the inlines of src[from:to], nested depth levels deep in
+// links and emphasis.
+func (p *inlineParser) render(b *strings.Builder, from, to, depth int) {
+	if depth >= markdownMaxNesting {
+		b.WriteString(html.EscapeString(p.src[from:to]))
+		return
+	}
+	s := p.src[:to]
+	for i := from; i < len(s); {
+		ch := s[i]
+		switch {
+		case ch == '\\' && i+1 < len(s) && s[i+1] == '\n':
+			b.WriteString("<br>\n")
+			i += 2
+			continue
+		case ch == '\\' && i+1 < len(s) && isASCIIPunct(s[i+1]):
+			b.WriteString(html.EscapeString(s[i+1 : i+2]))
+			i += 2
+			continue
+		case ch == ' ' && strings.HasPrefix(s[i:], "  \n"):
+			j := i
+			for j < len(s) && s[j] == ' ' {
+				j++
+			}
+			b.WriteString("<br>\n")
+			i = j + 1
+			continue
+		case ch == '`':
+			n := runLen(s[i:], '`')
+			if end := p.codeSpan(i, n, to); end >= 0 {
+				code := strings.ReplaceAll(s[i+n:end], "\n", " ")
+				if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' {
+					code = code[1 : len(code)-1]
+				}
+				b.WriteString("<code>" + html.EscapeString(code) + "</code>")
+				i = end + n
+				continue
+			}
+			b.WriteString(s[i : i+n])
+			i += n
+			continue
+		case ch == '!' && strings.HasPrefix(s[i:], "!["):
+			if textEnd, dest, title, end, ok := p.link(i+1, to); ok {
+				var alt strings.Builder
+				p.render(&alt, i+2, textEnd, depth+1)
+				b.WriteString(`<img src="` + safeURL(dest) + `" alt="` + html.EscapeString(html.UnescapeString(stripTags(alt.String()))) + `"`)
+				if title != "" {
+					b.WriteString(` title="` + html.EscapeString(title) + `"`)
+				}
+				b.WriteString(">")
+				i = end
+				continue
+			}
+		case ch == '[':
+			if textEnd, dest, title, end, ok := p.link(i, to); ok {
+				b.WriteString(`<a href="` + safeURL(dest) + `"`)
+				if title != "" {
+					b.WriteString(` title="` + html.EscapeString(title) + `"`)
+				}
+				b.WriteString(">")
+				p.render(b, i+1, textEnd, depth+1)
+				b.WriteString("</a>")
+				i = end
+				continue
+			}
+		case ch == '<':
+			// An autolink cannot contain spaces or angle brackets
+			if end := strings.IndexAny(s[i+1:], "<> \n"); end >= 0 && s[i+1+end] == '>' {
+				if u := s[i+1 : i+1+end]; isAutolink(u) {
+					b.WriteString(`<a href="` + safeURL(u) + `">` + html.EscapeString(strings.TrimPrefix(u, "mailto:")) + "</a>")
+					i += end + 2
+					continue
+				}
+			}
+		case ch == '*' || ch == '_' || ch == '~':
+			if n, end, ok := p.emphasis(i, to); ok {
+				tag := "em"
+				switch {
+				case ch == '~':
+					tag = "del"
+				case n == 2:
+					tag = "strong"
+				}
+				b.WriteString("<" + tag + ">")
+				p.render(b, i+n, end, depth+1)
+				b.WriteString("</" + tag + ">")
+				i = end + n
+				continue
+			}
+			n := runLen(s[i:], ch)
+			b.WriteString(s[i : i+n])
+			i += n
+			continue
+		}
+		b.WriteString(html.EscapeString(s[i : i+1]))
+		i++
+	}
+}
+
+// codeSpan returns the index of the run of n backticks closing the code
+// span opened at src[i], before to, or -1.
+func (p *inlineParser) codeSpan(i, n, to int) int {
+	if p.backticks == nil {
+		p.backticks = make(map[int][]int)
+		for j := 0; j < len(p.src); {
+			if p.src[j] != '`' {
+				j++
+				continue
+			}
+			run := runLen(p.src[j:], '`')
+			p.backticks[run] = append(p.backticks[run], j)
+			j += run
+		}
+	}
+	runs := p.backticks[n]
+	for len(runs) > 0 && runs[0] <= i {
+		runs = runs[1:]
+	}
+	p.backticks[n] = runs
+	if len(runs) == 0 || runs[0]+n > to {
+		return -1
+	}
+	return runs[0]
+}
+
+// emphasis finds the emphasis opened by the delimiter run at src[i],
+// closed before to. It returns the length of the delimiter and the index
+// of the closing one.
+func (p *inlineParser) emphasis(i, to int) (n, end int, ok bool) {
+	s := p.src[:to]
+	ch := s[i]
+	n = runLen(s[i:], ch)
+	switch {
+	case ch == '~' && n != 2:
+		return 0, 0, false
+	case n > 2:
+		n = 2
+	}
+	if ch == '_' && i > 0 && isIdentByte(s[i-1]) {
+		return 0, 0, false
+	}
+	if i+n >= len(s) || s[i+n] == ' ' || s[i+n] == '\n' {
+		return 0, 0, false
+	}
+	end = p.closer(s[i:i+n], i+n+1)
+	if end < 0 || end+n > to {
+		return 0, 0, false
+	}
+	return n, end, true
+}
+
+// closer returns the index of the first delimiter from src[j] on that
+// closes emphasis opened with delim, or -1. Since the inlines are parsed
+// from left to right, j never decreases and src is scanned once.
+func (p *inlineParser) closer(delim string, j int) int {
+	scan := p.closers[delim]
+	if scan == nil {
+		scan = &closerScan{found: -1}
+		p.closers[delim] = scan
+	}
+	if scan.found >= j {
+		return scan.found
+	}
+	if j < scan.scanned {
+		j = scan.scanned
+	}
+	s, n := p.src, len(delim)
+	for ; j+n <= len(s); j++ {
+		if s[j-1] == '\\' || s[j:j+n] != delim || s[j-1] == ' ' || s[j-1] == '\n' {
+			continue
+		}
+		if delim[0] == '_' && j+n < len(s) && isIdentByte(s[j+n]) {
+			continue
+		}
+		scan.found, scan.scanned = j, j+1
+		return j
+	}
+	scan.found, scan.scanned = -1, len(s)
+	return -1
+}
+
+// link parses the inline link "[text](dest "title")" starting at src[i]
+// and ending before to. It returns the index of the end of the text and
+// of the link.
+func (p *inlineParser) link(i, to int) (textEnd int, dest, title string, end int, ok bool) {
+	if p.brackets == nil {
+		p.brackets, p.parens = matchPairs(p.src, '[', ']'), matchPairs(p.src, '(', ')')
+	}
+	closeText, ok := p.brackets[i]
+	if !ok || closeText+1 >= to || p.src[closeText+1] != '(' {
+		return 0, "", "", 0, false
+	}
+	closeDest, ok := p.parens[closeText+1]
+	if !ok || closeDest >= to {
+		return 0, "", "", 0, false
+	}
+	inner := p.src[closeText+2 : closeDest]
+	if nl := strings.IndexByte(inner, '\n'); nl >= 0 && strings.TrimSpace(inner[:nl]) == "" {
+		return 0, "", "", 0, false
+	}
+	dest = strings.TrimSpace(inner)
+	if sp := strings.IndexAny(dest, " \n"); sp >= 0 {
+		dest, title = dest[:sp], strings.TrimSpace(dest[sp+1:])
+		if len(title) < 2 || title[0] != title[len(title)-1] || (title[0] != '"' && title[0] != '\'') {
+			return 0, "", "", 0, false
+		}
+		title = title[1 : len(title)-1]
+	}
+	dest = strings.TrimSuffix(strings.TrimPrefix(dest, "<"), ">")
+	return closeText, dest, title, closeDest + 1, true
+}
+
+// matchPairs maps the index of each open byte of s to the index of the
+// close byte matching it, skipping the bytes escaped by a backslash.
+func matchPairs(s string, open, close byte) map[int]int {
+	pairs := make(map[int]int)
+	var stack []int
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case open:
+			stack = append(stack, i)
+		case close:
+			if len(stack) > 0 {
+				pairs[stack[len(stack)-1]] = i
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+	return pairs
+}
+
+// safeURL returns u escaped for an attribute, or "#" if its scheme could
+// run code, such as javascript:.
+func safeURL(u string) string {
+	u = strings.TrimSpace(u)
+	if colon := strings.IndexByte(u, ':'); colon >= 0 && !strings.ContainsAny(u[:colon], "/?#") {
+		switch strings.ToLower(u[:colon]) {
+		case "http", "https", "mailto":
+		default:
+			return "#"
+		}
+	}
+	return html.EscapeString(u)
+}
+
+func isAutolink(u string) bool {
+	if strings.ContainsAny(u, " <>\n") {
+		return false
+	}
+	lower := strings.ToLower(u)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "mailto:")
+}
+
+func atxHeading(line string) (int, string, bool) {
+	level := runLen(line, '#')
+	if level == 0 || level > 6 || (level < len(line) && line[level] != ' ') {
+		return 0, "", false
+	}
+	text := strings.TrimSpace(line[level:])
+	if trimmed := strings.TrimRight(text, "#"); trimmed == "" || strings.HasSuffix(trimmed, " ") {
+		text = strings.TrimSpace(trimmed)
+	}
+	return level, text, true
+}
+
+func isThematicBreak(line string) bool {
+	ch := line[0]
+	if ch != '-' && ch != '*' && ch != '_' {
+		return false
+	}
+	n := 0
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case ch:
+			n++
+		case ' ':
+		default:
+			return false
+		}
+	}
+	return n >= 3
+}
+
+// codeFence returns the fence opening a fenced code block, or "".
+func codeFence(line string) string {
+	for _, ch := range []byte{'`', '~'} {
+		if n := runLen(line, ch); n >= 3 {
+			if ch == '`' && strings.IndexByte(line[n:], '`') >= 0 {
+				return ""
+			}
+			return line[:n]
+		}
+	}
+	return ""
+}
+
+// startsBlock reports whether line starts a block ending a paragraph.
+func startsBlock(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	if _, _, ok := atxHeading(trimmed); ok {
+		return true
+	}
+	return codeFence(trimmed) != "" || strings.HasPrefix(trimmed, ">") || isThematicBreak(trimmed)
+}
+
+func leadingSpaces(s string) int {
+	n := 0
+	for n < len(s) && s[n] == ' ' {
+		n++
+	}
+	return n
+}
+
+// cutIndent removes up to n spaces of indentation from line.
+func cutIndent(line string, n int) string {
+	spaces := leadingSpaces(line)
+	if spaces > n {
+		spaces = n
+	}
+	return line[spaces:]
+}
+
+func runLen(s string, ch byte) int {
+	n := 0
+	for n < len(s) && s[n] == ch {
+		n++
+	}
+	return n
+}
+
+func isASCIIPunct(ch byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", ch) >= 0
+}
+
+// stripTags returns the text of the HTML s.
+func stripTags(s string) string {
+	var b strings.Builder
+	inTag := false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '<':
+			inTag = true
+		case s[i] == '>' && inTag:
+			inTag = false
+		case !inTag:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,140 @@
+package filesystem
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/common/test/assert"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{name: "Should render headings with anchors", src: "# Hello *World*\n\n## Hello World\n\nSetext\n---", want: `<h1 id="hello-world">Hello <em>World</em> <a class="anchor" href="#hello-world" aria-hidden="true">#</a></h1>` + "\n" +
+			`<h2 id="hello-world-1">Hello World <a class="anchor" href="#hello-world-1" aria-hidden="true">#</a></h2>` + "\n" +
+			`<h2 id="setext">Setext <a class="anchor" href="#setext" aria-hidden="true">#</a></h2>` + "\n"},
+		{name: "Should render inlines", src: "**a** _b_ ~~c~~ `<d>` snake_case\\\nnext", want: "<p><strong>a</strong> <em>b</em> <del>c</del> <code>&lt;d&gt;</code> snake_case<br>\nnext</p>\n"},
+		{name: "Should render links and images", src: `[a](/x "t") ![b](y.png) <https://e.org>`, want: `<p><a href="/x" title="t">a</a> <img src="y.png" alt="b"> <a href="https://e.org">https://e.org</a></p>` + "\n"},
+		{name: "Should neutralize script links", src: "[a](javascript:alert(1)) [b](JavaScript:x)", want: `<p><a href="#">a</a> <a href="#">b</a></p>` + "\n"},
+		{name: "Should escape raw HTML", src: "<script>alert(1)</script>", want: "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>\n"},
+		{name: "Should render tight and nested lists", src: "- a\n- b\n  1. c\n- [ ] d", want: "<ul>\n<li>a</li>\n<li>b\n<ol>\n<li>c</li>\n</ol></li>\n<li><input type=\"checkbox\" disabled> d</li>\n</ul>\n"},
+		{name: "Should render loose lists", src: "3. a\n\n4. b", want: "<ol start=\"3\">\n<li><p>a</p></li>\n<li><p>b</p></li>\n</ol>\n"},
+		{name: "Should render block quotes and rules", src: "> a\n> b\n\n***", want: "<blockquote>\n<p>a\nb</p>\n</blockquote>\n<hr>\n"},
+		{name: "Should render tables", src: "| a | b |\n|:-:|---|\n| 1 | x \\| y |", want: "<table>\n<thead>\n<tr><th style=\"text-align:center\">a</th><th>b</th></tr>\n</thead>\n<tbody>\n" +
+			"<tr><td style=\"text-align:center\">1</td><td>x | y</td></tr>\n</tbody>\n</table>\n"},
+		{name: "Should highlight fenced code", src: "```go\nx := \"s\" // c\n```", want: `<pre><code class="language-go">x := <span class="hl-string">&#34;s&#34;</span> <span class="hl-comment">// c</span>` + "\n</code></pre>\n"},
+		{name: "Should escape indented code", src: "    <b>\n", want: "<pre><code>&lt;b&gt;\n</code></pre>\n"},
+		{name: "Should render nested links and emphasis", src: "*a [b `c`](d)* [[e](f)](g) **h", want: `<p><em>a <a href="d">b <code>c</code></a></em> <a href="g"><a href="f">e</a></a> **h</p>` + "\n"},
+		{name: "Should not close code spans with longer runs", src: "`a``b` ``c", want: "<p><code>a``b</code> ``c</p>\n"},
+		{name: "Should keep blank lines in list items", src: "- a\n\n\n  b\n- c", want: "<ul>\n<li><p>a</p>\n<p>b</p></li>\n<li><p>c</p></li>\n</ul>\n"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, _ := renderMarkdown([]byte(tt.src))
+			assert.DeepEqual(t, tt.want, got)
+		})
+	}
+}
+
+func TestHighlightCode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		lang string
+		code string
+		want string
+	}{
+		{lang: "py", code: "def f(): return 1 # x", want: `<span class="hl-keyword">def</span> f(): <span class="hl-keyword">return</span> <span class="hl-number">1</span> <span class="hl-comment"># x</span>`},
+		{lang: "SQL", code: "SELECT * FROM t", want: `<span class="hl-keyword">SELECT</span> * <span class="hl-keyword">FROM</span> t`},
+		{lang: "js", code: "/* a */ `b\nc`", want: "<span class=\"hl-comment\">/* a */</span> <span class=\"hl-string\">`b\nc`</span>"},
+		{lang: "unknown", code: "if <x>", want: "if &lt;x&gt;"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.lang, func(t *testing.T) {
+			t.Parallel()
+			assert.DeepEqual(t, tt.want, highlightCode(tt.lang, tt.code))
+		})
+	}
+}
+
+func TestRenderMarkdownNesting(t *testing.T) {
+	t.Parallel()
+
+	var nested strings.Builder
+	for i := 0; i < 100; i++ {
+		nested.WriteString(strings.Repeat("  ", i) + "- a\n")
+	}
+	tests := []struct {
+		name string
+		src  string
+		tag  string
+	}{
+		{name: "Should limit nested lists", src: nested.String(), tag: "<ul>"},
+		{name: "Should limit nested list markers", src: strings.Repeat("1. ", 100) + "a", tag: "<ol>"},
+		{name: "Should limit nested block quotes", src: strings.Repeat("> ", 100) + "a", tag: "<blockquote>"},
+		{name: "Should limit nested links", src: strings.Repeat("[", 100) + "a" + strings.Repeat("](b)", 100), tag: "<a "},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, _ := renderMarkdown([]byte(tt.src))
+			assert.DeepEqual(t, markdownMaxNesting, strings.Count(got, tt.tag))
+		})
+	}
+}
+
+func FuzzRenderMarkdown(f *testing.F) {
+	for _, seed := range []string{
+		"# a *b*\n\nc",
+		"- a\n  - b\n\n1. c",
+		"> a\n> > b",
+		"*a* _b_ **c** ~~d~~ `e`",
+		"[a](b \"c\") ![d](e) <https://f>",
+		"[a](javascript:b) <JavaScript:c>",
+		"| a | b |\n|---|:-:|\n| c | d |",
+		"```go\nx := `y` /* z */\n```",
+		"<script>a</script>",
+	} {
+		f.Add(seed)
+	}
+	tags := make(map[string]bool)
+	for _, tag := range strings.Fields("p h1 h2 h3 h4 h5 h6 a img ul ol li input blockquote hr br pre code span em strong del table thead tbody tr th td") {
+		tags[tag], tags["/"+tag] = true, true
+	}
+	f.Fuzz(func(t *testing.T, src string) {
+		got, _ := renderMarkdown([]byte(src))
+		for i := strings.IndexByte(got, '<'); i >= 0; i = strings.IndexByte(got[i+1:], '<') + i + 1 {
+			name := got[i+1:]
+			if end := strings.IndexAny(name, " >"); end >= 0 {
+				name = name[:end]
+			}
+			if !tags[name] {
+				t.Fatalf("%q: %q has the tag %q", src, got, name)
+			}
+			if !strings.Contains(got[i+1:], ">") {
+				t.Fatalf("%q: %q has an unclosed tag", src, got)
+			}
+			if strings.IndexByte(got[i+1:], '<') < 0 {
+				break
+			}
+		}
+		for _, attr := range []string{`href="`, `src="`} {
+			for _, value := range strings.Split(got, attr)[1:] {
+				if colon := strings.IndexByte(value, ':'); colon >= 0 && !strings.ContainsAny(value[:colon], "/?#\"") {
+					if scheme := strings.ToLower(value[:colon]); scheme != "http" && scheme != "https" && scheme != "mailto" {
+						t.Fatalf("%q: %q links to the scheme %q", src, got, scheme)
+					}
+				}
+			}
+		}
+	})
+}
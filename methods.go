@@ -0,0 +1,76 @@
+package filesystem
+
+import (
+	"context"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+// allowedMethods is the Allow header of the files served.
+const allowedMethods = "GET, HEAD, OPTIONS"
+
+// registeredMethods are the methods Register always routes to the handler.
+// The other methods are left to the routes of the application, such as an
+// upload handler on the same paths, unless they are passed to Register.
+var registeredMethods = []string{
+	consts.MethodGet,
+	consts.MethodHead,
+	consts.MethodOptions,
+}
+
+// OtherMethods are the methods a handler answers with a 405, to be passed
+// to Register so that they get one instead of the 404 of the router.
+var OtherMethods = []string{
+	consts.MethodPost,
+	consts.MethodPut,
+	consts.MethodPatch,
+	consts.MethodDelete,
+	consts.MethodConnect,
+	consts.MethodTrace,
+}
+
+// exists reports whether relPath, the request path relative to the
+// handler, resolves to a file or a directory of the root. Fallbacks such
+// as the not found file do not count.
+func (o *option) exists(original []byte, relPath string) bool {
+	path, err := cleanRequestPath(original, relPath, o.pathNormalizer)
+	if err != nil {
+		return false
+	}
+	path = o.pathPrefix + path
+	if len(path) > 1 {
+		path = trimRight(path, '/')
+	}
+	res, err := o.resolve(path, nil)
+	if err != nil || res.file == nil {
+		return false
+	}
+	_ = res.file.Close()
+	return strings.HasPrefix(res.via, "$uri")
+}
+
+// isPreflight reports whether the request is a CORS preflight request.
+func isPreflight(c *app.RequestContext) bool {
+	return string(c.Method()) == consts.MethodOptions && len(c.Request.Header.Peek("Access-Control-Request-Method")) > 0
+}
+
+// serveOtherMethod answers a request for relPath with a method other than
+// GET and HEAD: OPTIONS gets the allowed methods, and the CORS preflight
+// headers of WithCORS, other methods get a 405.
+func serveOtherMethod(ctx context.Context, c *app.RequestContext, cfg *option, relPath string) {
+	if !cfg.exists(c.URI().PathOriginal(), relPath) {
+		abortWithError(ctx, c, cfg, consts.StatusNotFound)
+		return
+	}
+	c.Response.Header.Set("Allow", allowedMethods)
+	if string(c.Method()) != consts.MethodOptions {
+		abortWithError(ctx, c, cfg, consts.StatusMethodNotAllowed)
+		return
+	}
+	if cfg.cors != nil {
+		setPreflightHeaders(c, cfg.cors)
+	}
+	c.AbortWithStatus(consts.StatusNoContent)
+}
@@ -0,0 +1,96 @@
+package filesystem
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/test/assert"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+func TestMethods(t *testing.T) {
+	t.Parallel()
+
+	h := server.New()
+	NewFSHandler(h, "/fs", http.Dir("./examples/testdata/fs"), WithNotFoundFile("index.html"), WithMethodNotAllowed(true))
+	Register(h, "/reg", NewHandler(http.Dir("./examples/testdata/fs")), OtherMethods...)
+	NewFSHandler(h, "/plain", http.Dir("./examples/testdata/fs"))
+	h.POST("/plain/*filepath", func(_ context.Context, c *app.RequestContext) { c.String(consts.StatusCreated, "upload") })
+	h.Use(New("/mw", http.Dir("./examples/testdata/fs")))
+	h.POST("/mw/*any", func(_ context.Context, c *app.RequestContext) { c.String(consts.StatusCreated, "api") })
+
+	tests := []struct {
+		name       string
+		method     string
+		url        string
+		statusCode int
+		allow      string
+		body       string
+	}{
+		{name: "Should answer OPTIONS with the allowed methods", method: consts.MethodOptions, url: "/fs/index.html", statusCode: 204, allow: allowedMethods},
+		{name: "Should answer OPTIONS for directories", method: consts.MethodOptions, url: "/plain/css/", statusCode: 204, allow: allowedMethods},
+		{name: "Should reject other methods", method: consts.MethodPost, url: "/fs/index.html", statusCode: 405, allow: allowedMethods},
+		{name: "Should reject DELETE", method: consts.MethodDelete, url: "/fs/css/style.css", statusCode: 405, allow: allowedMethods},
+		{name: "Should not count the not found file", method: consts.MethodPost, url: "/fs/missing.html", statusCode: 404},
+		{name: "Should reject the methods passed to Register", method: consts.MethodPut, url: "/reg/index.html", statusCode: 405, allow: allowedMethods},
+		{name: "Should leave other methods to the application", method: consts.MethodPost, url: "/plain/index.html", statusCode: 201, body: "upload"},
+		{name: "Should reject other methods in the middleware", method: consts.MethodPost, url: "/mw/index.html", statusCode: 405, allow: allowedMethods},
+		{name: "Should pass missing files through the middleware", method: consts.MethodPost, url: "/mw/missing", statusCode: 201, body: "api"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := ut.PerformRequest(h.Engine, tt.method, tt.url, nil)
+			response := w.Result()
+			assert.DeepEqual(t, tt.statusCode, response.StatusCode())
+			assert.DeepEqual(t, tt.allow, string(response.Header.Peek("Allow")))
+			if tt.body != "" {
+				assert.DeepEqual(t, tt.body, string(response.Body()))
+			}
+		})
+	}
+}
+
+func TestMethodsBehindPreHandler(t *testing.T) {
+	t.Parallel()
+
+	h := server.New()
+	reject := WithPreHandler(func(context.Context, *app.RequestContext) (func(), bool) { return nil, false })
+	fs := NewHandler(http.Dir("./examples/testdata/fs"), reject)
+	Register(h, "/fs", fs, consts.MethodPost)
+	h.Use(New("/mw", http.Dir("./examples/testdata/fs"), reject))
+	h.POST("/mw/*any", func(_ context.Context, c *app.RequestContext) { c.String(consts.StatusCreated, "api") })
+
+	tests := []struct {
+		name       string
+		method     string
+		url        string
+		headers    []ut.Header
+		statusCode int
+	}{
+		{name: "Should reject GET", method: consts.MethodGet, url: "/fs/index.html", statusCode: 401},
+		{name: "Should reject other methods for files", method: consts.MethodPost, url: "/fs/index.html", statusCode: 401},
+		{name: "Should reject other methods for missing files", method: consts.MethodPost, url: "/fs/missing.html", statusCode: 401},
+		{name: "Should reject OPTIONS", method: consts.MethodOptions, url: "/fs/index.html", statusCode: 401},
+		{
+			name: "Should answer preflights", method: consts.MethodOptions, url: "/fs/index.html",
+			headers:    []ut.Header{{Key: "Access-Control-Request-Method", Value: "GET"}},
+			statusCode: 204,
+		},
+		{name: "Should pass files through the middleware", method: consts.MethodPost, url: "/mw/index.html", statusCode: 201},
+		{name: "Should pass missing files through the middleware", method: consts.MethodPost, url: "/mw/missing", statusCode: 201},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := ut.PerformRequest(h.Engine, tt.method, tt.url, nil, tt.headers...)
+			assert.DeepEqual(t, tt.statusCode, w.Result().StatusCode())
+		})
+	}
+}
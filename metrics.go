@@ -0,0 +1,262 @@
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the buckets of the
+// request duration histogram.
+var latencyBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metrics collects the metrics of the handlers it is passed to with
+// WithMetrics, labeled by the path prefix of the handler, and serves them
+// in the Prometheus text exposition format.
+type Metrics struct {
+	mu     sync.Mutex
+	mounts map[string]*mountMetrics
+	// openFiles is the number of files being streamed.
+	openFiles int64
+}
+
+// mountMetrics are the metrics of the handlers mounted on a path prefix.
+type mountMetrics struct {
+	requests    map[int]uint64
+	bytes       uint64
+	cacheHits   uint64
+	cacheMisses uint64
+	// compressedIn and compressedOut are the sizes of the files served
+	// with a Content-Encoding, and of their encoded bodies.
+	compressedIn  uint64
+	compressedOut uint64
+	// latency counts the requests per bucket of latencyBuckets, the last
+	// one being +Inf.
+	latency    []uint64
+	latencySum float64
+}
+
+// NewMetrics creates an empty set of metrics.
+func NewMetrics() *Metrics {
+	return &Metrics{mounts: make(map[string]*mountMetrics)}
+}
+
+// compressionKey is the key of the servedBody of a response in the request
+// context, for CompressionMiddleware.
+const compressionKey = "filesystem.servedBody"
+
+// servedBody is the file a handler of metrics answered a request with,
+// before the response is compressed.
+type servedBody struct {
+	metrics *Metrics
+	mount   string
+	size    int64
+}
+
+// mount returns the metrics of mount, creating them if needed. The caller
+// holds m.mu.
+func (m *Metrics) mount(mount string) *mountMetrics {
+	if mount == "" {
+		mount = "/"
+	}
+	mm, ok := m.mounts[mount]
+	if !ok {
+		mm = &mountMetrics{requests: make(map[int]uint64), latency: make([]uint64, len(latencyBuckets)+1)}
+		m.mounts[mount] = mm
+	}
+	return mm
+}
+
+// observe records the request of entry, and the size of the file it was
+// answered with for CompressionMiddleware.
+func (m *Metrics) observe(c *app.RequestContext, entry *AccessLogEntry, conditional bool) {
+	seconds := entry.Duration.Seconds()
+	if entry.File != "" && entry.Encoding == "" && entry.Bytes > 0 {
+		c.Set(compressionKey, servedBody{metrics: m, mount: entry.Mount, size: entry.Bytes})
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mm := m.mount(entry.Mount)
+	mm.requests[entry.Status]++
+	mm.bytes += uint64(entry.Bytes)
+	switch {
+	case entry.CacheHit:
+		mm.cacheHits++
+	case conditional:
+		mm.cacheMisses++
+	}
+	mm.latency[sort.SearchFloat64s(latencyBuckets, seconds)]++
+	mm.latencySum += seconds
+}
+
+// CompressionMiddleware returns a middleware recording the compression of
+// the files served by the handlers using m. The handlers run before the
+// responses are compressed, so it must be registered in front of the
+// compression middleware, such as
+// h.Use(metrics.CompressionMiddleware(), gzip.Gzip(gzip.DefaultCompression)).
+func (m *Metrics) CompressionMiddleware() app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		c.Next(ctx)
+		value, _ := c.Get(compressionKey)
+		served, ok := value.(servedBody)
+		if !ok || served.metrics != m || len(c.Response.Header.Peek("Content-Encoding")) == 0 {
+			return
+		}
+		encoded := int64(c.Response.Header.ContentLength())
+		if !c.Response.IsBodyStream() {
+			encoded = int64(len(c.Response.Body()))
+		}
+		if encoded < 0 {
+			return
+		}
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		mm := m.mount(served.mount)
+		mm.compressedIn += uint64(served.size)
+		mm.compressedOut += uint64(encoded)
+	}
+}
+
+// trackFile counts the body stream r of a file as open until it is closed.
+func (m *Metrics) trackFile(r io.ReadCloser) io.Reader {
+	atomic.AddInt64(&m.openFiles, 1)
+	return &trackedFile{ReadCloser: r, metrics: m}
+}
+
+// trackedFile is a body stream counted by Metrics.openFiles.
+type trackedFile struct {
+	io.ReadCloser
+	metrics *Metrics
+	closed  int32
+}
+
+func (f *trackedFile) Close() error {
+	if atomic.CompareAndSwapInt32(&f.closed, 0, 1) {
+		atomic.AddInt64(&f.metrics.openFiles, -1)
+	}
+	return f.ReadCloser.Close()
+}
+
+// Handler returns a handler serving the metrics, to be registered on a
+// route such as "/metrics".
+func (m *Metrics) Handler() app.HandlerFunc {
+	return func(_ context.Context, c *app.RequestContext) {
+		var b strings.Builder
+		_, _ = m.WriteTo(&b)
+		c.Data(consts.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(b.String()))
+	}
+}
+
+// WriteTo writes the metrics to w in the Prometheus text exposition format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	ew := &exposition{w: w}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mounts := make([]string, 0, len(m.mounts))
+	for mount := range m.mounts {
+		mounts = append(mounts, mount)
+	}
+	sort.Strings(mounts)
+
+	ew.header("filesystem_requests_total", "counter", "Requests handled, by mount and status.")
+	for _, mount := range mounts {
+		mm := m.mounts[mount]
+		statuses := make([]int, 0, len(mm.requests))
+		for status := range mm.requests {
+			statuses = append(statuses, status)
+		}
+		sort.Ints(statuses)
+		for _, status := range statuses {
+			ew.sample("filesystem_requests_total", labels("mount", mount, "status", strconv.Itoa(status)), float64(mm.requests[status]))
+		}
+	}
+	counters := []struct {
+		name, help string
+		value      func(mm *mountMetrics) uint64
+	}{
+		{"filesystem_response_bytes_total", "Bytes of the response bodies sent.", func(mm *mountMetrics) uint64 { return mm.bytes }},
+		{"filesystem_cache_hits_total", "Requests answered with 304 Not Modified.", func(mm *mountMetrics) uint64 { return mm.cacheHits }},
+		{"filesystem_cache_misses_total", "Conditional requests answered with the file.", func(mm *mountMetrics) uint64 { return mm.cacheMisses }},
+		{"filesystem_compression_input_bytes_total", "Size of the files compressed, recorded by CompressionMiddleware.", func(mm *mountMetrics) uint64 { return mm.compressedIn }},
+		{"filesystem_compression_output_bytes_total", "Size of the compressed bodies of the files, recorded by CompressionMiddleware.", func(mm *mountMetrics) uint64 { return mm.compressedOut }},
+	}
+	for _, counter := range counters {
+		ew.header(counter.name, "counter", counter.help)
+		for _, mount := range mounts {
+			ew.sample(counter.name, labels("mount", mount), float64(counter.value(m.mounts[mount])))
+		}
+	}
+
+	ew.header("filesystem_request_duration_seconds", "histogram", "Time spent handling the requests, by mount.")
+	for _, mount := range mounts {
+		mm := m.mounts[mount]
+		var count uint64
+		for i, n := range mm.latency {
+			count += n
+			le := "+Inf"
+			if i < len(latencyBuckets) {
+				le = strconv.FormatFloat(latencyBuckets[i], 'g', -1, 64)
+			}
+			ew.sample("filesystem_request_duration_seconds_bucket", labels("mount", mount, "le", le), float64(count))
+		}
+		ew.sample("filesystem_request_duration_seconds_sum", labels("mount", mount), mm.latencySum)
+		ew.sample("filesystem_request_duration_seconds_count", labels("mount", mount), float64(count))
+	}
+
+	ew.header("filesystem_open_files", "gauge", "Files being streamed.")
+	ew.sample("filesystem_open_files", "", float64(atomic.LoadInt64(&m.openFiles)))
+	return ew.n, ew.err
+}
+
+// exposition writes the lines of the exposition format, keeping the first
+// error.
+type exposition struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (e *exposition) printf(format string, args ...interface{}) {
+	if e.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(e.w, format, args...)
+	e.n += int64(n)
+	e.err = err
+}
+
+func (e *exposition) header(name, typ, help string) {
+	e.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func (e *exposition) sample(name, labels string, value float64) {
+	e.printf("%s%s %s\n", name, labels, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+// labels formats the label pairs of kv, names and values alternating.
+func labels(kv ...string) string {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i := 0; i+1 < len(kv); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(kv[i])
+		b.WriteString(`="`)
+		b.WriteString(strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(kv[i+1]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
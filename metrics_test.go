@@ -0,0 +1,118 @@
+package filesystem
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/test/assert"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	metrics := NewMetrics()
+	h := server.New()
+	NewFSHandler(h, "/static", http.Dir("./examples/testdata/fs"), WithMetrics(metrics))
+	NewFSHandler(h, "/other", http.Dir("./examples/testdata/fs"), WithMetrics(metrics))
+	h.GET("/metrics", metrics.Handler())
+
+	w := ut.PerformRequest(h.Engine, consts.MethodGet, "/static/css/style.css", nil)
+	size := len(w.Result().Body())
+	lastModified := w.Result().Header.Get(consts.HeaderLastModified)
+	ut.PerformRequest(h.Engine, consts.MethodGet, "/static/css/style.css", nil,
+		ut.Header{Key: consts.HeaderIfModifiedSince, Value: lastModified})
+	ut.PerformRequest(h.Engine, consts.MethodGet, "/static/css/style.css", nil,
+		ut.Header{Key: consts.HeaderIfModifiedSince, Value: "Mon, 01 Jan 1990 00:00:00 GMT"})
+	ut.PerformRequest(h.Engine, consts.MethodGet, "/other/missing", nil)
+
+	w = ut.PerformRequest(h.Engine, consts.MethodGet, "/metrics", nil)
+	assert.DeepEqual(t, "text/plain; version=0.0.4; charset=utf-8", string(w.Result().Header.ContentType()))
+	body := string(w.Result().Body())
+	for _, line := range []string{
+		"# TYPE filesystem_requests_total counter",
+		`filesystem_requests_total{mount="/static",status="200"} 2`,
+		`filesystem_requests_total{mount="/static",status="304"} 1`,
+		`filesystem_requests_total{mount="/other",status="404"} 1`,
+		`filesystem_cache_hits_total{mount="/static"} 1`,
+		`filesystem_cache_misses_total{mount="/static"} 1`,
+		`filesystem_cache_hits_total{mount="/other"} 0`,
+		"# TYPE filesystem_request_duration_seconds histogram",
+		`filesystem_request_duration_seconds_bucket{mount="/static",le="+Inf"} 3`,
+		`filesystem_request_duration_seconds_count{mount="/static"} 3`,
+		"# TYPE filesystem_open_files gauge",
+		"filesystem_open_files 0",
+	} {
+		assert.True(t, strings.Contains(body, line+"\n"))
+	}
+	assert.True(t, strings.Contains(body, "filesystem_response_bytes_total{mount=\"/static\"} "))
+	assert.True(t, size > 0)
+}
+
+func TestMetricsLabels(t *testing.T) {
+	t.Parallel()
+
+	assert.DeepEqual(t, `{mount="/a\"b\\c\n",le="1"}`, labels("mount", "/a\"b\\c\n", "le", "1"))
+}
+
+func TestMetricsCompression(t *testing.T) {
+	t.Parallel()
+
+	content, err := os.ReadFile("./examples/testdata/fs/css/style.css")
+	assert.Nil(t, err)
+
+	metrics := NewMetrics()
+	h := server.New()
+	// compress stands for a compression middleware such as the one of hertz-contrib/gzip
+	compress := func(ctx context.Context, c *app.RequestContext) {
+		c.Next(ctx)
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		_, _ = zw.Write(c.Response.Body())
+		_ = zw.Close()
+		c.Response.Header.Set("Content-Encoding", "gzip")
+		c.Response.SetBody(buf.Bytes())
+	}
+	h.Use(metrics.CompressionMiddleware(), compress)
+	NewFSHandler(h, "/static", http.Dir("./examples/testdata/fs"), WithMetrics(metrics))
+
+	w := ut.PerformRequest(h.Engine, consts.MethodGet, "/static/css/style.css", nil)
+	assert.DeepEqual(t, "gzip", w.Result().Header.Get("Content-Encoding"))
+	encoded := len(w.Result().Body())
+
+	var b strings.Builder
+	_, err = metrics.WriteTo(&b)
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(b.String(), fmt.Sprintf("filesystem_compression_input_bytes_total{mount=\"/static\"} %d\n", len(content))))
+	assert.True(t, strings.Contains(b.String(), fmt.Sprintf("filesystem_compression_output_bytes_total{mount=\"/static\"} %d\n", encoded)))
+}
+
+func TestMetricsOpenMappedFiles(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("mmap is only supported on linux")
+	}
+	t.Parallel()
+
+	metrics := NewMetrics()
+	f, err := os.Open("./examples/testdata/fs/css/style.css")
+	assert.Nil(t, err)
+	stat, err := f.Stat()
+	assert.Nil(t, err)
+
+	c := app.NewContext(0)
+	assert.True(t, serveMmap(context.Background(), c, &option{mappings: newMmapCache(), metrics: metrics}, f, stat))
+	assert.DeepEqual(t, int64(1), atomic.LoadInt64(&metrics.openFiles))
+	assert.Nil(t, c.Response.CloseBodyStream())
+	assert.DeepEqual(t, int64(0), atomic.LoadInt64(&metrics.openFiles))
+}
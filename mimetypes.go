@@ -0,0 +1,89 @@
+package filesystem
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// DefaultMIMERegistry is the registry of the handlers without
+// WithMIMERegistry. It starts with the built-in table.
+var DefaultMIMERegistry = NewMIMERegistry()
+
+// MIMERegistry maps file extensions to content types. It is safe for
+// concurrent use, so types can be registered while files are served.
+type MIMERegistry struct {
+	mu    sync.RWMutex
+	types map[string]string
+}
+
+// NewMIMERegistry creates a registry holding the built-in table.
+func NewMIMERegistry() *MIMERegistry {
+	r := &MIMERegistry{types: make(map[string]string, len(mimeExtensions))}
+	for ext, ct := range mimeExtensions {
+		r.types[ext] = ct
+	}
+	return r
+}
+
+// Lookup returns the content type of the extension ext, with or without
+// its leading dot, ignoring its case.
+func (r *MIMERegistry) Lookup(ext string) (string, bool) {
+	r.mu.RLock()
+	ct, ok := r.types[normalizeExt(ext)]
+	r.mu.RUnlock()
+	return ct, ok
+}
+
+// Register sets the content type of the extension ext, replacing the type
+// it had.
+func (r *MIMERegistry) Register(ext, contentType string) {
+	r.mu.Lock()
+	r.types[normalizeExt(ext)] = contentType
+	r.mu.Unlock()
+}
+
+// Load registers the types of a file in the mime.types format of Apache
+// and of /etc/mime.types: a content type followed by its extensions on
+// each line, "#" starting a comment.
+func (r *MIMERegistry) Load(reader io.Reader) error {
+	types := make(map[string]string)
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !strings.Contains(fields[0], "/") {
+			continue
+		}
+		for _, ext := range fields[1:] {
+			types[normalizeExt(ext)] = fields[0]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	for ext, ct := range types {
+		r.types[ext] = ct
+	}
+	r.mu.Unlock()
+	return nil
+}
+
+// LoadFile registers the types of the mime.types file at name, such as
+// "/etc/mime.types".
+func (r *MIMERegistry) LoadFile(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return r.Load(f)
+}
+
+func normalizeExt(ext string) string {
+	return strings.ToLower(strings.TrimPrefix(ext, "."))
+}
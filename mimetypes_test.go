@@ -0,0 +1,124 @@
+package filesystem
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/test/assert"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+func TestMIMERegistryBuiltins(t *testing.T) {
+	t.Parallel()
+
+	r := NewMIMERegistry()
+	tests := map[string]string{
+		"html":        "text/html",
+		".JS":         "text/javascript",
+		"mjs":         "text/javascript",
+		"map":         "application/json",
+		"webmanifest": "application/manifest+json",
+		"jsonld":      "application/ld+json",
+		"avif":        "image/avif",
+		"avifs":       "image/avif-sequence",
+		"glb":         "model/gltf-binary",
+		"gltf":        "model/gltf+json",
+		"opus":        "audio/opus",
+		"flac":        "audio/flac",
+		"woff2":       "font/woff2",
+		"wasm":        "application/wasm",
+	}
+	for ext, want := range tests {
+		ct, ok := r.Lookup(ext)
+		assert.True(t, ok)
+		assert.DeepEqual(t, want, ct)
+	}
+	_, ok := r.Lookup("unknown")
+	assert.False(t, ok)
+}
+
+func TestMIMERegistryLoad(t *testing.T) {
+	t.Parallel()
+
+	r := NewMIMERegistry()
+	err := r.Load(strings.NewReader(`# MIME type			Extensions
+application/vnd.example+json	exj exjson
+text/x-custom	cst # trailing comment
+
+application/x-no-extension
+text/html	HTML5
+`))
+	assert.Nil(t, err)
+
+	for ext, want := range map[string]string{
+		"exj":    "application/vnd.example+json",
+		"exjson": "application/vnd.example+json",
+		"cst":    "text/x-custom",
+		"html5":  "text/html",
+		"html":   "text/html",
+	} {
+		ct, ok := r.Lookup(ext)
+		assert.True(t, ok)
+		assert.DeepEqual(t, want, ct)
+	}
+
+	name := filepath.Join(t.TempDir(), "mime.types")
+	assert.Nil(t, os.WriteFile(name, []byte("application/x-from-file\tfff\n"), 0o644))
+	assert.Nil(t, r.LoadFile(name))
+	ct, _ := r.Lookup("fff")
+	assert.DeepEqual(t, "application/x-from-file", ct)
+	assert.NotNil(t, r.LoadFile(filepath.Join(t.TempDir(), "missing")))
+}
+
+func TestMIMERegistryConcurrency(t *testing.T) {
+	t.Parallel()
+
+	r := NewMIMERegistry()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			r.Register("conc", "application/x-conc")
+		}()
+		go func() {
+			defer wg.Done()
+			_, _ = r.Lookup("conc")
+		}()
+	}
+	wg.Wait()
+	ct, _ := r.Lookup("conc")
+	assert.DeepEqual(t, "application/x-conc", ct)
+}
+
+func TestMIMERegistryOption(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for _, name := range []string{"model.glb", "data.json"} {
+		assert.Nil(t, os.WriteFile(filepath.Join(root, name), []byte("x"), 0o644))
+	}
+	registry := NewMIMERegistry()
+	registry.Register("glb", "application/x-custom-glb")
+
+	h := server.New()
+	NewFSHandler(h, "/default", http.Dir(root))
+	NewFSHandler(h, "/registry", http.Dir(root), WithMIMERegistry(registry))
+	NewFSHandler(h, "/override", http.Dir(root), WithContentTypes(map[string]string{"json": "application/vnd.api+json"}))
+
+	for url, want := range map[string]string{
+		"/default/model.glb":  "model/gltf-binary",
+		"/registry/model.glb": "application/x-custom-glb",
+		"/override/data.json": "application/vnd.api+json",
+		"/override/model.glb": "model/gltf-binary",
+	} {
+		w := ut.PerformRequest(h.Engine, consts.MethodGet, url, nil)
+		assert.DeepEqual(t, want, string(w.Result().Header.ContentType()))
+	}
+}
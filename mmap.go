@@ -0,0 +1,357 @@
+package filesystem
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+var (
+	errMmapUnsupported     = errors.New("mmap is not supported on this platform")
+	errMmapTooLarge        = errors.New("file is too large to be mapped")
+	errMmapTruncated       = errors.New("mapped file was truncated")
+	errNoRange             = errors.New("no single byte range requested")
+	errRangeNotSatisfiable = errors.New("requested range not satisfiable")
+)
+
+const (
+	// mmapIdleTimeout is how long a mapping no request reads is kept.
+	mmapIdleTimeout = time.Minute
+	// mmapSweepInterval is the interval at which idle mappings are checked
+	// against their file, while there are some.
+	mmapSweepInterval = 10 * time.Second
+	// mmapMaxIdle is the number of idle mappings kept, the least recently
+	// used ones being unmapped first.
+	mmapMaxIdle = 256
+)
+
+// mmapCache keeps the memory mappings of os-backed files, so that concurrent
+// readers of the same file share a single mapping.
+//
+// Idle mappings are unmapped once their file is changed or removed, after
+// mmapIdleTimeout, or when there are more than mmapMaxIdle of them. The
+// sweeps are only scheduled while there are idle mappings, so that a cache
+// that is no longer used releases all of its mappings.
+type mmapCache struct {
+	mu       sync.Mutex
+	mappings map[string]*mapping
+	// sweeping is true while a sweep is scheduled.
+	sweeping bool
+}
+
+// mapping is a reference counted memory mapping of a file.
+//
+// A mapping becomes stale when the file changes on disk, it is then dropped
+// from the cache and unmapped as soon as its last reader releases it.
+type mapping struct {
+	cache *mmapCache
+	name  string
+	data  []byte
+	stat  os.FileInfo
+	refs  int
+	stale bool
+	// lastUsed is when the last reader released the mapping.
+	lastUsed time.Time
+}
+
+func newMmapCache() *mmapCache {
+	return &mmapCache{mappings: make(map[string]*mapping)}
+}
+
+// acquire returns the mapping of f with its reference count incremented.
+// The caller must call release once it is done with the mapped data.
+func (mc *mmapCache) acquire(f *os.File, stat os.FileInfo) (*mapping, error) {
+	name := f.Name()
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	if m, ok := mc.mappings[name]; ok {
+		if !changed(m.stat, stat) {
+			m.refs++
+			return m, nil
+		}
+		mc.drop(m)
+	}
+
+	data, err := mmapFile(f, stat.Size())
+	if err != nil {
+		return nil, err
+	}
+	m := &mapping{cache: mc, name: name, data: data, stat: stat, refs: 1}
+	mc.mappings[name] = m
+	return m, nil
+}
+
+// release drops a reference taken by acquire.
+func (m *mapping) release() {
+	mc := m.cache
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	m.refs--
+	if m.refs > 0 {
+		return
+	}
+	if m.stale {
+		m.unmap()
+		return
+	}
+	m.lastUsed = time.Now()
+	mc.evictIdle(mmapMaxIdle)
+	if !mc.sweeping {
+		mc.sweeping = true
+		time.AfterFunc(mmapSweepInterval, mc.sweep)
+	}
+}
+
+// drop removes m from the cache, unmapping it if it has no reader. The
+// caller holds mc.mu.
+func (mc *mmapCache) drop(m *mapping) {
+	if mc.mappings[m.name] == m {
+		delete(mc.mappings, m.name)
+	}
+	m.stale = true
+	if m.refs == 0 {
+		m.unmap()
+	}
+}
+
+// evictIdle unmaps the least recently used idle mappings beyond max. The
+// caller holds mc.mu.
+func (mc *mmapCache) evictIdle(max int) {
+	var idle []*mapping
+	for _, m := range mc.mappings {
+		if m.refs == 0 {
+			idle = append(idle, m)
+		}
+	}
+	if len(idle) <= max {
+		return
+	}
+	sort.Slice(idle, func(i, j int) bool { return idle[i].lastUsed.Before(idle[j].lastUsed) })
+	for _, m := range idle[:len(idle)-max] {
+		mc.drop(m)
+	}
+}
+
+// sweep unmaps the idle mappings that expired or whose file changed, and
+// schedules the next sweep if idle mappings are left.
+func (mc *mmapCache) sweep() {
+	now := time.Now()
+	var expired, idle []*mapping
+	mc.mu.Lock()
+	for _, m := range mc.mappings {
+		switch {
+		case m.refs > 0:
+		case now.Sub(m.lastUsed) >= mmapIdleTimeout:
+			expired = append(expired, m)
+		default:
+			idle = append(idle, m)
+		}
+	}
+	mc.mu.Unlock()
+
+	// The files are checked without holding the lock.
+	for _, m := range idle {
+		if stat, err := os.Stat(m.name); err != nil || changed(m.stat, stat) {
+			expired = append(expired, m)
+		}
+	}
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	for _, m := range expired {
+		// The mapping may have been read again meanwhile.
+		if m.refs == 0 && !m.stale {
+			mc.drop(m)
+		}
+	}
+	mc.sweeping = false
+	for _, m := range mc.mappings {
+		if m.refs == 0 {
+			mc.sweeping = true
+			time.AfterFunc(mmapSweepInterval, mc.sweep)
+			break
+		}
+	}
+}
+
+func (m *mapping) unmap() {
+	if m.data == nil {
+		return
+	}
+	if err := munmap(m.data); err != nil {
+		hlog.SystemLogger().Errorf("failed to munmap %s: %s", m.stat.Name(), err)
+	}
+	m.data = nil
+}
+
+// changed reports whether the file described by cur is not the one that
+// was mapped, or was modified after it has been mapped.
+func changed(old, cur os.FileInfo) bool {
+	return !os.SameFile(old, cur) || old.Size() != cur.Size() || !old.ModTime().Equal(cur.ModTime())
+}
+
+// mmapReader serves a slice of a mapping and releases it on Close, which is
+// called by hertz once the body stream has been written.
+type mmapReader struct {
+	r    *bytes.Reader
+	m    *mapping
+	once sync.Once
+}
+
+// Read reads the mapped data. A file truncated while it is read faults on
+// the pages past its new end, with SIGBUS: the fault is turned into an
+// error, ending the response, instead of crashing the process.
+func (r *mmapReader) Read(p []byte) (n int, err error) {
+	prev := debug.SetPanicOnFault(true)
+	defer func() {
+		debug.SetPanicOnFault(prev)
+		if e := recover(); e != nil {
+			if _, ok := e.(runtime.Error); !ok {
+				panic(e)
+			}
+			r.m.cache.mu.Lock()
+			r.m.cache.drop(r.m)
+			r.m.cache.mu.Unlock()
+			n, err = 0, errMmapTruncated
+		}
+	}()
+	return r.r.Read(p)
+}
+
+func (r *mmapReader) Close() error {
+	r.once.Do(r.m.release)
+	return nil
+}
+
+// serveMmap writes the content of file to the response from a memory
+// mapping of cfg. A single byte range is honoured if one is requested, as
+// long as the response is a 200 and If-Range, if any, matches the file.
+//
+// It returns false if file is not backed by the os or cannot be mapped,
+// in which case the caller falls back to normal reads. The mapped file is
+// counted as open in the metrics of cfg until it is written.
+func serveMmap(ctx context.Context, c *app.RequestContext, cfg *option, file http.File, stat os.FileInfo) bool {
+	f, ok := file.(*os.File)
+	if !ok || stat.Size() == 0 {
+		return false
+	}
+	m, err := cfg.mappings.acquire(f, stat)
+	if err != nil {
+		if err != errMmapUnsupported {
+			hlog.SystemLogger().Warnf("failed to mmap %s, falling back to reads: %s", f.Name(), err)
+		}
+		return false
+	}
+	// The mapping stays valid after the descriptor is closed.
+	if err := f.Close(); err != nil {
+		hlog.SystemLogger().Errorf("failed to close: %s", err)
+	}
+
+	size := stat.Size()
+	start, end, err := int64(0), size-1, errNoRange
+	if c.Response.StatusCode() == consts.StatusOK {
+		c.Response.Header.Set("Accept-Ranges", "bytes")
+		if ifRangeMatches(string(c.Request.Header.Peek("If-Range")), stat.ModTime()) {
+			start, end, err = parseByteRange(string(c.Request.Header.Peek("Range")), size)
+		}
+	}
+	switch err {
+	case nil:
+		c.Response.SetStatusCode(consts.StatusPartialContent)
+		c.Response.Header.Set("Content-Range", "bytes "+strconv.FormatInt(start, 10)+"-"+
+			strconv.FormatInt(end, 10)+"/"+strconv.FormatInt(size, 10))
+	case errRangeNotSatisfiable:
+		m.release()
+		c.Response.Header.Set("Content-Range", "bytes */"+strconv.FormatInt(size, 10))
+		abortWithError(ctx, c, cfg, consts.StatusRequestedRangeNotSatisfiable)
+		return true
+	default:
+		start, end = 0, size-1
+	}
+
+	r := &mmapReader{r: bytes.NewReader(m.data[start : end+1]), m: m}
+	var body io.Reader = r
+	if cfg.metrics != nil {
+		body = cfg.metrics.trackFile(r)
+	}
+	c.Response.SetBodyStream(body, int(end-start+1))
+	return true
+}
+
+// ifRangeMatches reports whether the If-Range header ifRange lets a range
+// of a file last modified at modTime be served: it is missing, or is the
+// Last-Modified of the file. Entity tags never match, files having none.
+func ifRangeMatches(ifRange string, modTime time.Time) bool {
+	if ifRange == "" {
+		return true
+	}
+	t, err := http.ParseTime(ifRange)
+	return err == nil && !modTime.IsZero() && t.Equal(modTime.UTC().Truncate(time.Second))
+}
+
+// parseByteRange parses a "bytes=" Range header value against a content of
+// the given size and returns the first and last byte of the range.
+//
+// errNoRange is returned when the header is missing, malformed or asks for
+// several ranges, in which case the whole content should be served.
+func parseByteRange(header string, size int64) (start, end int64, err error) {
+	spec := strings.TrimPrefix(header, "bytes=")
+	if spec == header || spec == "" || strings.Contains(spec, ",") {
+		return 0, 0, errNoRange
+	}
+	first, last, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return 0, 0, errNoRange
+	}
+
+	if first == "" {
+		// Suffix range: the last n bytes.
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n < 0 {
+			return 0, 0, errNoRange
+		}
+		if n == 0 {
+			return 0, 0, errRangeNotSatisfiable
+		}
+		if n > size {
+			n = size
+		}
+		return size - n, size - 1, nil
+	}
+
+	start, err = strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return 0, 0, errNoRange
+	}
+	if start >= size {
+		return 0, 0, errRangeNotSatisfiable
+	}
+	end = size - 1
+	if last != "" {
+		end, err = strconv.ParseInt(last, 10, 64)
+		if err != nil || end < start {
+			return 0, 0, errNoRange
+		}
+		if end >= size {
+			end = size - 1
+		}
+	}
+	return start, end, nil
+}
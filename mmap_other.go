@@ -0,0 +1,13 @@
+//go:build !linux
+
+package filesystem
+
+import "os"
+
+func mmapFile(*os.File, int64) ([]byte, error) {
+	return nil, errMmapUnsupported
+}
+
+func munmap([]byte) error {
+	return nil
+}
@@ -0,0 +1,255 @@
+package filesystem
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/test/assert"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+func TestMmap(t *testing.T) {
+	t.Parallel()
+
+	content, err := os.ReadFile("./examples/testdata/fs/css/style.css")
+	assert.Nil(t, err)
+	stat, err := os.Stat("./examples/testdata/fs/css/style.css")
+	assert.Nil(t, err)
+	lastModified := stat.ModTime().UTC().Format(http.TimeFormat)
+
+	h := server.New()
+	NewFSHandler(h, "/mmap", http.Dir("./examples/testdata/fs"), WithMmap(true))
+
+	tests := []struct {
+		name       string
+		rangeValue string
+		ifRange    string
+		statusCode int
+		body       string
+	}{
+		{
+			name:       "Should serve the whole file",
+			statusCode: 200,
+			body:       string(content),
+		},
+		{
+			name:       "Should serve the requested range",
+			rangeValue: "bytes=0-3",
+			statusCode: 206,
+			body:       string(content[:4]),
+		},
+		{
+			name:       "Should serve the requested suffix",
+			rangeValue: "bytes=-5",
+			statusCode: 206,
+			body:       string(content[len(content)-5:]),
+		},
+		{
+			name:       "Should ignore multiple ranges",
+			rangeValue: "bytes=0-1,3-4",
+			statusCode: 200,
+			body:       string(content),
+		},
+		{
+			name:       "Should serve the range of an unchanged file",
+			rangeValue: "bytes=0-3",
+			ifRange:    lastModified,
+			statusCode: 206,
+			body:       string(content[:4]),
+		},
+		{
+			name:       "Should serve a changed file whole",
+			rangeValue: "bytes=0-3",
+			ifRange:    stat.ModTime().Add(-time.Hour).UTC().Format(http.TimeFormat),
+			statusCode: 200,
+			body:       string(content),
+		},
+		{
+			name:       "Should serve the whole file for entity tags",
+			rangeValue: "bytes=0-3",
+			ifRange:    `"etag"`,
+			statusCode: 200,
+			body:       string(content),
+		},
+		{
+			name:       "Should reject an unsatisfiable range",
+			rangeValue: "bytes=100000-",
+			statusCode: 416,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var headers []ut.Header
+			if tt.rangeValue != "" {
+				headers = append(headers, ut.Header{Key: "Range", Value: tt.rangeValue})
+			}
+			if tt.ifRange != "" {
+				headers = append(headers, ut.Header{Key: "If-Range", Value: tt.ifRange})
+			}
+			w := ut.PerformRequest(h.Engine, consts.MethodGet, "/mmap/css/style.css", nil, headers...)
+			response := w.Result()
+			if runtime.GOOS != "linux" {
+				return
+			}
+			assert.DeepEqual(t, tt.statusCode, response.StatusCode())
+			if tt.body != "" {
+				assert.DeepEqual(t, tt.body, string(response.Body()))
+			}
+		})
+	}
+}
+
+func TestMmapRangeStatus(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("mmap is only supported on linux")
+	}
+	t.Parallel()
+
+	root := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "nf.txt"), []byte("not found"), 0o644))
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "data.txt"), []byte("data"), 0o644))
+
+	h := server.New()
+	NewFSHandler(h, "/mmap", http.Dir(root), WithMmap(true), WithTryFiles("$uri", "/nf.txt =404"),
+		WithErrorPages(map[int]ErrorPage{416: {File: "/nf.txt"}}))
+
+	tests := []struct {
+		name       string
+		url        string
+		rangeValue string
+		statusCode int
+		body       string
+	}{
+		{name: "Should serve other statuses whole", url: "/mmap/missing", rangeValue: "bytes=0-1", statusCode: 404, body: "not found"},
+		{name: "Should answer unsatisfiable ranges with the error page", url: "/mmap/data.txt", rangeValue: "bytes=10-", statusCode: 416, body: "not found"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := ut.PerformRequest(h.Engine, consts.MethodGet, tt.url, nil, ut.Header{Key: "Range", Value: tt.rangeValue})
+			response := w.Result()
+			assert.DeepEqual(t, tt.statusCode, response.StatusCode())
+			assert.DeepEqual(t, tt.body, string(response.Body()))
+			assert.DeepEqual(t, tt.statusCode == 416, len(response.Header.Peek("Content-Range")) > 0)
+		})
+	}
+}
+
+func TestMmapCacheReplacesChangedFiles(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("mmap is only supported on linux")
+	}
+	name := filepath.Join(t.TempDir(), "data.txt")
+	assert.Nil(t, os.WriteFile(name, []byte("hello"), 0o644))
+
+	mc := newMmapCache()
+	acquire := func() *mapping {
+		f, err := os.Open(name)
+		assert.Nil(t, err)
+		defer f.Close()
+		stat, err := f.Stat()
+		assert.Nil(t, err)
+		m, err := mc.acquire(f, stat)
+		assert.Nil(t, err)
+		return m
+	}
+
+	first := acquire()
+	second := acquire()
+	assert.True(t, first == second)
+	assert.DeepEqual(t, 2, first.refs)
+
+	assert.Nil(t, os.WriteFile(name, []byte("hello world"), 0o644))
+	assert.Nil(t, os.Chtimes(name, time.Now(), time.Now().Add(time.Minute)))
+	third := acquire()
+	assert.False(t, first == third)
+	assert.DeepEqual(t, "hello world", string(third.data))
+	assert.True(t, first.stale)
+	assert.DeepEqual(t, "hello", string(first.data))
+
+	first.release()
+	second.release()
+	assert.Nil(t, first.data)
+	third.release()
+	assert.NotNil(t, third.data)
+}
+
+func TestMmapCacheEvictsIdleMappings(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("mmap is only supported on linux")
+	}
+	dir := t.TempDir()
+	mc := newMmapCache()
+	acquire := func(name string) *mapping {
+		f, err := os.Open(filepath.Join(dir, name))
+		assert.Nil(t, err)
+		defer f.Close()
+		stat, err := f.Stat()
+		assert.Nil(t, err)
+		m, err := mc.acquire(f, stat)
+		assert.Nil(t, err)
+		return m
+	}
+	for _, name := range []string{"kept", "removed", "expired", "read"} {
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, name), []byte(name), 0o644))
+	}
+
+	kept, removed, expired, read := acquire("kept"), acquire("removed"), acquire("expired"), acquire("read")
+	for _, m := range []*mapping{kept, removed, expired} {
+		m.release()
+	}
+	assert.Nil(t, os.Remove(filepath.Join(dir, "removed")))
+	mc.mu.Lock()
+	expired.lastUsed = expired.lastUsed.Add(-mmapIdleTimeout)
+	mc.mu.Unlock()
+
+	mc.sweep()
+	assert.NotNil(t, kept.data)
+	assert.Nil(t, removed.data)
+	assert.Nil(t, expired.data)
+	assert.NotNil(t, read.data)
+	assert.DeepEqual(t, 2, len(mc.mappings))
+	read.release()
+
+	mc.mu.Lock()
+	mc.evictIdle(1)
+	mc.mu.Unlock()
+	assert.Nil(t, kept.data)
+	assert.NotNil(t, read.data)
+}
+
+func TestMmapReaderTruncatedFile(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("mmap is only supported on linux")
+	}
+	name := filepath.Join(t.TempDir(), "data.bin")
+	assert.Nil(t, os.WriteFile(name, make([]byte, 3*os.Getpagesize()), 0o644))
+
+	mc := newMmapCache()
+	f, err := os.Open(name)
+	assert.Nil(t, err)
+	defer f.Close()
+	stat, err := f.Stat()
+	assert.Nil(t, err)
+	m, err := mc.acquire(f, stat)
+	assert.Nil(t, err)
+
+	r := &mmapReader{r: bytes.NewReader(m.data), m: m}
+	assert.Nil(t, os.Truncate(name, 0))
+	_, err = io.ReadAll(r)
+	assert.DeepEqual(t, errMmapTruncated, err)
+	assert.True(t, m.stale)
+	assert.Nil(t, r.Close())
+	assert.Nil(t, m.data)
+}
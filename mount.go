@@ -0,0 +1,126 @@
+package filesystem
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/cloudwego/hertz/pkg/route"
+)
+
+// Mount is a root served under a path of NewMountHandler.
+type Mount struct {
+	// Path is where the root is mounted, relative to the prefix of the
+	// handler. An empty path or "/" mounts the root on the prefix itself.
+	Path    string
+	Root    http.FileSystem
+	Options []Option
+}
+
+// mountPoint is a mount as seen from the mount containing it.
+type mountPoint struct {
+	// path is where the mount is, relative to the containing mount.
+	path string
+	cfg  *option
+}
+
+// NewMountHandler serves several roots under relpath, each request being
+// served by the mount with the longest path containing it. Directory
+// listings of a mount show the mounts nested in it, even where the
+// directory does not exist in its root. Like Register, it can register on
+// route groups.
+func NewMountHandler(router route.IRoutes, relpath string, mounts ...Mount) {
+	var table []mountPoint
+	for _, m := range mounts {
+		p := "/" + strings.Trim(m.Path, "/")
+		if p == "/" {
+			p = ""
+		}
+		duplicate := false
+		for _, mp := range table {
+			duplicate = duplicate || mp.path == p
+		}
+		if duplicate {
+			hlog.SystemLogger().Errorf("Duplicate mount %q", m.Path)
+			continue
+		}
+		table = append(table, mountPoint{path: p, cfg: newOption(m.Root, m.Options)})
+	}
+	sort.Slice(table, func(i, j int) bool {
+		return len(table[i].path) > len(table[j].path)
+	})
+	for _, parent := range table {
+		for _, child := range table {
+			if child.path != parent.path && strings.HasPrefix(child.path, parent.path+"/") {
+				parent.cfg.mountPoints = append(parent.cfg.mountPoints, mountPoint{
+					path: strings.TrimPrefix(child.path, parent.path),
+					cfg:  child.cfg,
+				})
+			}
+		}
+	}
+
+	logicFunc := func(ctx context.Context, c *app.RequestContext) {
+		base := routeMount(c, c.Param("filepath"))
+		path, err := cleanRequestPath(c.URI().PathOriginal(), c.Param("filepath"), nil)
+		if err != nil {
+			hlog.SystemLogger().Warnf("Rejected request path %q: %s", c.URI().PathOriginal(), err)
+			abortWithError(ctx, c, nil, consts.StatusBadRequest)
+			return
+		}
+		for _, mp := range table {
+			if mp.path == "" || path == mp.path || strings.HasPrefix(path, mp.path+"/") {
+				serve(ctx, c, mp.cfg, base+mp.path, strings.TrimPrefix(path, mp.path))
+				return
+			}
+		}
+		abortWithError(ctx, c, nil, consts.StatusNotFound)
+	}
+	Register(router, relpath, logicFunc)
+}
+
+// mountEntries returns the entries the mounts nested in o add to the
+// listing of the directory at urlPath.
+func (o *option) mountEntries(urlPath string) []os.FileInfo {
+	dir := trimRight(urlPath, '/') + "/"
+	var entries []os.FileInfo
+	seen := make(map[string]bool)
+	for _, mp := range o.mountPoints {
+		if !strings.HasPrefix(mp.path, dir) {
+			continue
+		}
+		name, _, _ := strings.Cut(strings.TrimPrefix(mp.path, dir), "/")
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		entry := mountEntry{name: name}
+		if f, err := mp.cfg.root.Open(mp.cfg.pathPrefix + "/"); err == nil {
+			if stat, err := f.Stat(); err == nil {
+				entry.modTime = stat.ModTime()
+			}
+			_ = f.Close()
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// mountEntry is the directory entry of a mount point.
+type mountEntry struct {
+	name    string
+	modTime time.Time
+}
+
+func (e mountEntry) Name() string       { return e.name }
+func (e mountEntry) Size() int64        { return 0 }
+func (e mountEntry) Mode() os.FileMode  { return os.ModeDir | 0o555 }
+func (e mountEntry) ModTime() time.Time { return e.modTime }
+func (e mountEntry) IsDir() bool        { return true }
+func (e mountEntry) Sys() interface{}   { return nil }
@@ -0,0 +1,97 @@
+package filesystem
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/test/assert"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+func TestMountHandler(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	files := map[string]string{
+		"base/app.js":           "app",
+		"base/uploads/old.png":  "shadowed",
+		"uploads/photo.png":     "photo",
+		"vendor/lib/jquery.js":  "jquery",
+		"vendor/lib/nested.txt": "nested",
+	}
+	for name, content := range files {
+		name = filepath.Join(root, filepath.FromSlash(name))
+		assert.Nil(t, os.MkdirAll(filepath.Dir(name), 0o755))
+		assert.Nil(t, os.WriteFile(name, []byte(content), 0o644))
+	}
+
+	h := server.New()
+	NewMountHandler(h, "/static",
+		Mount{Root: http.Dir(filepath.Join(root, "base")), Options: []Option{WithBrowse(true)}},
+		Mount{Path: "/uploads", Root: http.Dir(filepath.Join(root, "uploads")), Options: []Option{WithMaxAge(60)}},
+		Mount{Path: "/third/party/vendor/", Root: http.Dir(filepath.Join(root, "vendor"))},
+	)
+
+	tests := []struct {
+		name         string
+		url          string
+		statusCode   int
+		body         string
+		contains     []string
+		cacheControl string
+	}{
+		{name: "Should serve the base mount", url: "/static/app.js", statusCode: 200, body: "app"},
+		{name: "Should serve the longest mount", url: "/static/uploads/photo.png", statusCode: 200, body: "photo", cacheControl: "public, max-age=60"},
+		{name: "Should shadow the base mount", url: "/static/uploads/old.png", statusCode: 404},
+		{name: "Should serve a deep mount", url: "/static/third/party/vendor/lib/jquery.js", statusCode: 200, body: "jquery"},
+		{name: "Should dispatch cleaned paths", url: "/static/third/../uploads/photo.png", statusCode: 200, body: "photo"},
+		{name: "Should list mount points", url: "/static/", statusCode: 200, contains: []string{`href="/static/app.js"`, `href="/static/uploads"`, `href="/static/third"`}},
+		{name: "Should list missing directories of mount points", url: "/static/third/", statusCode: 200, contains: []string{`href="/static/third/party"`}},
+		{name: "Should not list without browsing", url: "/static/third/party/vendor/lib/", statusCode: 403},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := ut.PerformRequest(h.Engine, consts.MethodGet, tt.url, nil)
+			response := w.Result()
+			assert.DeepEqual(t, tt.statusCode, response.StatusCode())
+			if tt.body != "" {
+				assert.DeepEqual(t, tt.body, string(response.Body()))
+			}
+			for _, s := range tt.contains {
+				assert.True(t, strings.Contains(string(response.Body()), s))
+			}
+			if tt.cacheControl != "" {
+				assert.DeepEqual(t, tt.cacheControl, string(response.Header.Peek("Cache-Control")))
+			}
+		})
+	}
+}
+
+func TestMountHandlerGroup(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "app.js"), []byte("app"), 0o644))
+
+	h := server.New()
+	group := h.Group("/g", func(ctx context.Context, c *app.RequestContext) {
+		c.Response.Header.Set("X-Group", "g")
+		c.Next(ctx)
+	})
+	NewMountHandler(group, "/static", Mount{Root: http.Dir(root), Options: []Option{WithBrowse(true)}})
+
+	w := ut.PerformRequest(h.Engine, consts.MethodGet, "/g/static/app.js", nil)
+	assert.DeepEqual(t, "app", string(w.Result().Body()))
+	assert.DeepEqual(t, "g", w.Result().Header.Get("X-Group"))
+	w = ut.PerformRequest(h.Engine, consts.MethodGet, "/g/static/", nil)
+	assert.True(t, strings.Contains(string(w.Result().Body()), `href="/g/static/app.js"`))
+}
@@ -3,7 +3,10 @@ package filesystem
 import (
 	"context"
 	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"golang.org/x/text/unicode/norm"
 	"net/http"
+	"os"
 	"strings"
 )
 
@@ -16,6 +19,56 @@ type option struct {
 	index        string
 	maxAge       int
 	notFoundFile string
+	mappings     *mmapCache
+	cacheRules   []CacheRule
+	headerRules  *rulesFile
+
+	redirectRules []redirectRule
+	redirectsFile *rulesFile
+	countryHeader string
+
+	tryFiles      []tryFile
+	spa           *spaOption
+	cleanURLs     bool
+	trailingSlash TrailingSlash
+
+	pathNormalizer func(string) string
+	nfc            bool
+
+	caseInsensitive CaseInsensitiveMode
+	caseIndex       *caseIndex
+
+	languages       []string
+	defaultLanguage string
+	languageQuery   string
+	languageCookie  string
+	languageDirs    bool
+
+	pathParam        string
+	methodNotAllowed bool
+	cors             *CORS
+	disposition      *Disposition
+
+	contentTypes map[string]string
+	mimeRegistry *MIMERegistry
+	sniff        bool
+	charset      string
+	noSniff      bool
+
+	accessLog        AccessLogSink
+	principal        func(c *app.RequestContext) string
+	notFoundLogLevel hlog.Level
+	metrics          *Metrics
+	hooks            *Hooks
+
+	errorPages map[int]ErrorPage
+	markdown   *markdownOption
+	templates  *templatesOption
+	injection  *injectionOption
+	liveReload *liveReloadOption
+
+	// mountPoints are the mounts nested in this one by NewMountHandler.
+	mountPoints []mountPoint
 }
 
 type Option func(o *option)
@@ -24,8 +77,11 @@ type preHandler func(c context.Context, ctx *app.RequestContext) (func(), bool)
 
 func newOption(root http.FileSystem, opts []Option) *option {
 	cfg := &option{
-		root:  root,
-		index: "index.html",
+		root:             root,
+		index:            "index.html",
+		countryHeader:    DefaultCountryHeader,
+		pathParam:        "filepath",
+		notFoundLogLevel: hlog.LevelError,
 	}
 	for _, optionFuc := range opts {
 		optionFuc(cfg)
@@ -38,12 +94,72 @@ func newOption(root http.FileSystem, opts []Option) *option {
 		cfg.notFoundFile = "/" + cfg.notFoundFile
 	}
 
+	for status, page := range cfg.errorPages {
+		if page.File != "" && !strings.HasPrefix(page.File, "/") {
+			page.File = "/" + page.File
+			cfg.errorPages[status] = page
+		}
+	}
+
+	if cfg.nfc {
+		normalize := cfg.pathNormalizer
+		cfg.pathNormalizer = func(p string) string {
+			p = norm.NFC.String(p)
+			if normalize != nil {
+				p = normalize(p)
+			}
+			return p
+		}
+	}
+
+	if cfg.caseInsensitive != CaseInsensitiveOff {
+		cfg.caseIndex = newCaseIndex()
+	}
+
+	if len(cfg.tryFiles) == 0 {
+		cfg.tryFiles = []tryFile{{path: "$uri"}}
+		if cfg.cleanURLs {
+			cfg.tryFiles = append(cfg.tryFiles, tryFile{path: cleanURLCandidate})
+		}
+	}
+	if cfg.notFoundFile != "" {
+		cfg.tryFiles = append(cfg.tryFiles, tryFile{path: cfg.notFoundFile})
+	}
+
 	if cfg.pathPrefix != "" && !strings.HasPrefix(cfg.pathPrefix, "/") {
 		cfg.pathPrefix = "/" + cfg.pathPrefix
 	}
+
+	if cfg.liveReload != nil {
+		if err := cfg.liveReload.watch(cfg.root); err != nil {
+			hlog.SystemLogger().Errorf("Live reload is disabled: %s", err)
+			cfg.liveReload = nil
+		}
+	}
 	return cfg
 }
 
+// open opens name in the root, hiding the rules files stored there.
+func (o *option) open(name string) (http.File, error) {
+	for _, rf := range []*rulesFile{o.headerRules, o.redirectsFile} {
+		if rf != nil && rf.inRoot && name == rf.name {
+			return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+		}
+	}
+	return o.root.Open(name)
+}
+
+// isFile reports whether name is a regular file in the root.
+func (o *option) isFile(name string) bool {
+	f, err := o.open(name)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	return err == nil && !stat.IsDir()
+}
+
 // WithPathPrefix PathPrefix defines a prefix to be added to a filepath when
 // reading a file from the FileSystem.
 //
@@ -70,6 +186,8 @@ func WithIndexFile(index string) Option {
 
 // WithMaxAge The value for the Cache-Control HTTP-header
 // that is set on the file response. MaxAge is defined in seconds.
+//
+// It applies to the files not matched by any rule of WithCacheRules.
 func WithMaxAge(age int) Option {
 	return func(o *option) {
 		o.maxAge = age
@@ -77,6 +195,8 @@ func WithMaxAge(age int) Option {
 }
 
 // WithNotFoundFile File to return if path is not found. Useful for SPA's.
+//
+// It is tried after the candidates of WithTryFiles.
 func WithNotFoundFile(path string) Option {
 	return func(o *option) {
 		o.notFoundFile = path
@@ -93,3 +213,415 @@ func WithPreHandler(handler preHandler) Option {
 		o.preHandler = handler
 	}
 }
+
+// WithMmap Serve files of an os-backed root, such as http.Dir, from shared
+// memory mappings instead of reading them for every request. Single byte
+// ranges of 200 responses are served as slices of the mapping, honouring
+// If-Range.
+//
+// Mappings are reference counted and replaced when the file changes on disk.
+// Idle mappings are unmapped after a minute, or once their file is changed
+// or removed. A file truncated while it is served ends the response with
+// an error rather than crashing the process with SIGBUS.
+// Only supported on Linux, other platforms fall back to normal reads.
+func WithMmap(enabled bool) Option {
+	return func(o *option) {
+		if !enabled {
+			o.mappings = nil
+			return
+		}
+		o.mappings = newMmapCache()
+	}
+}
+
+// WithCacheRules Cache policies applied to the files they match, the first
+// matching rule wins. The headers are set alike on GET, HEAD and 304
+// responses.
+//
+// For example, fingerprinted assets can be cached forever while html pages
+// are always revalidated:
+//
+//	WithCacheRules(
+//		CacheRule{
+//			Regexp: regexp.MustCompile(`\.[0-9a-f]{8,}\.(js|css)$`),
+//			Policy: CachePolicy{Public: true, MaxAge: 365 * 24 * time.Hour, Immutable: true},
+//		},
+//		CacheRule{Glob: "*.html", Policy: CachePolicy{NoCache: true}},
+//	)
+func WithCacheRules(rules ...CacheRule) Option {
+	return func(o *option) {
+		o.cacheRules = append(o.cacheRules, rules...)
+	}
+}
+
+// WithHeadersFile Custom response headers loaded from a file in the root, in
+// the format of the _headers file of static hosting platforms:
+//
+//	/*
+//	  X-Frame-Options: DENY
+//	/fonts/:name
+//	  Access-Control-Allow-Origin: *
+//
+// Path patterns are matched against the request path relative to the
+// handler. The file is reloaded when it changes and is never served itself.
+func WithHeadersFile(name string) Option {
+	return func(o *option) {
+		o.headerRules = newRulesFile(o.root, name, parseHeaderRules)
+		o.headerRules.inRoot = true
+	}
+}
+
+// WithHeadersFileFS Like WithHeadersFile, but the file is read from fs,
+// for example http.Dir("/etc/site") to load it from disk.
+func WithHeadersFileFS(fs http.FileSystem, name string) Option {
+	return func(o *option) {
+		o.headerRules = newRulesFile(fs, name, parseHeaderRules)
+	}
+}
+
+// WithRedirects Redirect and rewrite rules evaluated, in order, before the
+// requested file is opened. They take precedence over the rules loaded by
+// WithRedirectsFile. Invalid rules are logged and ignored.
+func WithRedirects(rules ...RedirectRule) Option {
+	return func(o *option) {
+		for _, rule := range rules {
+			compiled, err := compileRedirectRule(rule)
+			if err != nil {
+				hlog.SystemLogger().Errorf("invalid redirect rule: %s", err)
+				continue
+			}
+			o.redirectRules = append(o.redirectRules, compiled)
+		}
+	}
+}
+
+// WithRedirectsFile Redirect and rewrite rules loaded from a file in the
+// root, in the format of the _redirects file of static hosting platforms:
+//
+//	/old/*        /new/:splat   301
+//	/app/*        /index.html   200
+//
+// The file is reloaded when it changes and is never served itself.
+func WithRedirectsFile(name string) Option {
+	return func(o *option) {
+		o.redirectsFile = newRulesFile(o.root, name, parseRedirectRules)
+		o.redirectsFile.inRoot = true
+	}
+}
+
+// WithRedirectsFileFS Like WithRedirectsFile, but the file is read from fs,
+// for example http.Dir("/etc/site") to load it from disk.
+func WithRedirectsFileFS(fs http.FileSystem, name string) Option {
+	return func(o *option) {
+		o.redirectsFile = newRulesFile(fs, name, parseRedirectRules)
+	}
+}
+
+// WithCountryHeader The request header holding the country code matched by
+// the Country condition of redirect rules. Defaults to DefaultCountryHeader.
+func WithCountryHeader(name string) Option {
+	return func(o *option) {
+		o.countryHeader = name
+	}
+}
+
+// WithTryFiles Resolve requests through an ordered list of candidates, like
+// the try_files directive of nginx. "$uri" stands for the requested path,
+// including the WithPathPrefix prefix, and a candidate ending with "/" only
+// matches a directory, whose index file is served. A candidate may be
+// followed by "=code" to be served with that status, and a lone "=code"
+// ends the chain with that status:
+//
+//	WithTryFiles("$uri", "$uri.html", "$uri/", "/404.html =404")
+//
+// Invalid candidates are logged and ignored. The default chain is "$uri".
+func WithTryFiles(candidates ...string) Option {
+	return func(o *option) {
+		for _, candidate := range candidates {
+			tf, err := parseTryFile(candidate)
+			if err != nil {
+				hlog.SystemLogger().Errorf("invalid try files candidate: %s", err)
+				continue
+			}
+			o.tryFiles = append(o.tryFiles, tf)
+		}
+	}
+}
+
+// WithSPA Serve the fallback page, usually the index.html of a single page
+// application, for browser navigations to missing paths: requests accepting
+// text/html, without file extension and not under one of apiPrefixes.
+//
+// Unlike WithNotFoundFile, missing assets get a real 404, and the fallback
+// is sent with "Cache-Control: no-cache".
+func WithSPA(fallback string, apiPrefixes ...string) Option {
+	return func(o *option) {
+		if !strings.HasPrefix(fallback, "/") {
+			fallback = "/" + fallback
+		}
+		spa := &spaOption{fallback: fallback}
+		for _, prefix := range apiPrefixes {
+			if !strings.HasPrefix(prefix, "/") {
+				prefix = "/" + prefix
+			}
+			spa.apiPrefixes = append(spa.apiPrefixes, trimRight(prefix, '/'))
+		}
+		o.spa = spa
+	}
+}
+
+// WithCleanURLs Serve html files without their extension: /about resolves to
+// /about.html or /about/index.html, while requests for /about.html and
+// /about/index.html are redirected with a 301.
+//
+// It extends the default try files chain, and has no effect on the chain
+// set by WithTryFiles but for the redirects.
+func WithCleanURLs(enabled bool) Option {
+	return func(o *option) {
+		o.cleanURLs = enabled
+	}
+}
+
+// WithTrailingSlash The trailing slash policy, enforced with 301 redirects.
+// Defaults to TrailingSlashPreserve, which does not redirect.
+func WithTrailingSlash(policy TrailingSlash) Option {
+	return func(o *option) {
+		o.trailingSlash = policy
+	}
+}
+
+// WithPathNormalizer A function applied to every decoded and cleaned request
+// path before it is resolved, after the NFC normalization of WithNFC.
+func WithPathNormalizer(normalize func(string) string) Option {
+	return func(o *option) {
+		o.pathNormalizer = normalize
+	}
+}
+
+// WithNFC Normalize the decoded request paths to Unicode NFC, so that the
+// paths sent decomposed, as macOS does, find the files named composed, such
+// as "e%CC%81" finding "é".
+func WithNFC(enabled bool) Option {
+	return func(o *option) {
+		o.nfc = enabled
+	}
+}
+
+// WithCaseInsensitive Resolve the requested path case-insensitively when it
+// does not exist as is, matching each segment against the names in its
+// directory. Directory listings are cached until the directory changes.
+// When names only differ by case, the smallest in byte order wins.
+//
+// CaseInsensitiveServe serves the matching file, CaseInsensitiveRedirect
+// redirects to its canonical path.
+func WithCaseInsensitive(mode CaseInsensitiveMode) Option {
+	return func(o *option) {
+		o.caseInsensitive = mode
+	}
+}
+
+// WithLanguages Serve the variant of the requested file in the language the
+// request prefers among languages, such as index.de.html or
+// index.zh-CN.html for index.html, falling back to the variant in
+// defaultLanguage and then to the file itself. Responses get
+// Content-Language and Vary: Accept-Language.
+func WithLanguages(defaultLanguage string, languages ...string) Option {
+	return func(o *option) {
+		o.defaultLanguage = defaultLanguage
+		o.languages = append([]string(nil), languages...)
+		if _, ok := o.availableLanguage(defaultLanguage); !ok {
+			o.languages = append(o.languages, defaultLanguage)
+		}
+	}
+}
+
+// WithLanguageOverride The query parameter and the cookie that force the
+// language of WithLanguages over Accept-Language, the query parameter
+// taking precedence. An empty name disables the override.
+func WithLanguageOverride(query, cookie string) Option {
+	return func(o *option) {
+		o.languageQuery = query
+		o.languageCookie = cookie
+	}
+}
+
+// WithLanguageDirectories Look for the variants of WithLanguages in a
+// directory per language, /de/index.html for /index.html, instead of next
+// to the file. Requests under such a directory are served as is.
+func WithLanguageDirectories(enabled bool) Option {
+	return func(o *option) {
+		o.languageDirs = enabled
+	}
+}
+
+// WithPathParam The name of the route parameter holding the path of the
+// file for the handler of NewHandler. Defaults to "filepath".
+func WithPathParam(name string) Option {
+	return func(o *option) {
+		o.pathParam = name
+	}
+}
+
+// WithMethodNotAllowed Route the methods of OtherMethods to the handler of
+// NewFSHandler too, which answers them with a 405 and the Allow header for
+// existing files instead of the 404 of the router. The application can then
+// no longer register these methods on the same paths.
+func WithMethodNotAllowed(enabled bool) Option {
+	return func(o *option) {
+		o.methodNotAllowed = enabled
+	}
+}
+
+// WithCORS The cross-origin resource sharing policy of the files, so that
+// fonts and assets can be loaded from other origins. Preflight requests
+// are answered before WithPreHandler runs.
+func WithCORS(cors CORS) Option {
+	return func(o *option) {
+		o.cors = &cors
+	}
+}
+
+// WithDisposition The Content-Disposition policy, serving files as
+// attachments on demand or by rule, with a validated or signed filename.
+func WithDisposition(disposition Disposition) Option {
+	return func(o *option) {
+		o.disposition = &disposition
+	}
+}
+
+// WithContentTypes The content types of file extensions for this handler
+// only, taking precedence over the MIME registry, such as
+// {"json": "application/vnd.api+json"}.
+func WithContentTypes(types map[string]string) Option {
+	return func(o *option) {
+		o.contentTypes = make(map[string]string, len(types))
+		for ext, ct := range types {
+			o.contentTypes[normalizeExt(ext)] = ct
+		}
+	}
+}
+
+// WithMIMERegistry The registry of the content types of file extensions,
+// in place of DefaultMIMERegistry.
+func WithMIMERegistry(registry *MIMERegistry) Option {
+	return func(o *option) {
+		o.mimeRegistry = registry
+	}
+}
+
+// WithContentSniffing Detect the content type of files whose extension is
+// unknown, or missing, from their first 512 bytes.
+func WithContentSniffing(enabled bool) Option {
+	return func(o *option) {
+		o.sniff = enabled
+	}
+}
+
+// WithCharset The charset added to the content type of text files, such as
+// "utf-8". Empty, the default, adds none.
+func WithCharset(charset string) Option {
+	return func(o *option) {
+		o.charset = charset
+	}
+}
+
+// WithNoSniff Send X-Content-Type-Options: nosniff, so that browsers stick
+// to the content type of the files.
+func WithNoSniff(enabled bool) Option {
+	return func(o *option) {
+		o.noSniff = enabled
+	}
+}
+
+// WithAccessLog The sink receiving an entry per request, such as
+// NewAccessLogWriter(os.Stdout, AccessLogCombined).
+func WithAccessLog(sink AccessLogSink) Option {
+	return func(o *option) {
+		o.accessLog = sink
+	}
+}
+
+// WithPrincipal A function returning the user a request was made by, for
+// the access log, for example from a value set by an authentication
+// middleware.
+func WithPrincipal(principal func(c *app.RequestContext) string) Option {
+	return func(o *option) {
+		o.principal = principal
+	}
+}
+
+// WithNotFoundLogLevel The level missing files are logged at. Defaults to
+// hlog.LevelError.
+func WithNotFoundLogLevel(level hlog.Level) Option {
+	return func(o *option) {
+		o.notFoundLogLevel = level
+	}
+}
+
+// WithMetrics The metrics the requests are recorded in, labeled by the path
+// prefix of the handler. Metrics can be shared by several handlers.
+func WithMetrics(metrics *Metrics) Option {
+	return func(o *option) {
+		o.metrics = metrics
+	}
+}
+
+// WithHooks Callbacks called at each stage of serving a request, after
+// WithPreHandler: once the path is known, once the file is opened, before
+// its headers are written, and when no file is found or it fails.
+func WithHooks(hooks Hooks) Option {
+	return func(o *option) {
+		o.hooks = &hooks
+	}
+}
+
+// WithErrorPages The pages answering the requests failing with a status, a
+// page of status 0 answering the other statuses. Clients preferring JSON
+// get RFC 9457 problem details instead, unless the page is a Handler.
+func WithErrorPages(pages map[int]ErrorPage) Option {
+	return func(o *option) {
+		o.errorPages = make(map[int]ErrorPage, len(pages))
+		for status, page := range pages {
+			o.errorPages[status] = page
+		}
+	}
+}
+
+// WithMarkdown Render the Markdown files as HTML pages through a layout,
+// with a table of contents, heading anchors and highlighted code. The
+// pages are cached until their file is modified.
+func WithMarkdown(markdown Markdown) Option {
+	return func(o *option) {
+		o.markdown = &markdownOption{Markdown: markdown, pages: make(map[string]renderedPage)}
+	}
+}
+
+// WithTemplates Execute the matching files as html/template with the data
+// of the request. The templates are parsed with their partials, and cached
+// until one of their files is modified. Partials are checked for changes at
+// most once a second.
+func WithTemplates(templates Templates) Option {
+	return func(o *option) {
+		o.templates = &templatesOption{Templates: templates, parsed: make(map[string]parsedTemplate)}
+	}
+}
+
+// WithConfigInjection Inject runtime configuration into the HTML index and
+// fallback pages, as a window.__CONFIG__ script and a <base href> of the
+// path prefix. The modified pages are cached and served with their ETag.
+func WithConfigInjection(injection ConfigInjection) Option {
+	return func(o *option) {
+		o.injection = newInjectionOption(injection)
+	}
+}
+
+// WithLiveReload Development mode: watch the http.Dir root, serve the
+// changes as Server-Sent Events under the path prefix, add a client
+// reloading the HTML pages, or only their stylesheets, and disable caching.
+// The handlers of a directory share its watcher, which is stopped once
+// LiveReload.Done of each of them is closed, or they are garbage collected.
+func WithLiveReload(liveReload LiveReload) Option {
+	return func(o *option) {
+		o.liveReload = newLiveReloadOption(liveReload)
+	}
+}
@@ -0,0 +1,349 @@
+package filesystem
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+// DefaultCountryHeader is the request header holding the country code
+// matched by the Country condition of redirect rules.
+const DefaultCountryHeader = "X-Country"
+
+// RedirectRule redirects or rewrites the requests whose path matches From.
+//
+// Unless Force is set, a rule does not apply when a file exists at the
+// requested path, so that rules only fill in for missing content.
+type RedirectRule struct {
+	// From is the path pattern, relative to the handler, in which ":name"
+	// matches a single segment and a trailing "*" matches the rest of the
+	// path as ":splat".
+	From string
+	// Query lists the query parameters the request must have. A value
+	// starting with ":" captures the parameter as a placeholder, any other
+	// value must match exactly. A rule does not match if a captured value
+	// holds a slash or a backslash, or is "." or "..".
+	Query map[string]string
+	// To is the target, either a path relative to the handler or an
+	// absolute URL. Placeholders captured by From and Query are expanded.
+	To string
+	// Status is 301, 302, 303, 307 or 308 to redirect to To. Any other
+	// status serves To in place of the requested path with that status,
+	// for example 200 for a rewrite or 404 for a custom not found page.
+	// It defaults to 301.
+	Status int
+	// Force applies the rule even if a file exists at the requested path.
+	Force bool
+	// Conditions restricts the rule to the requests whose "Country" or
+	// "Language" is one of the listed values. The country is read from
+	// the header set by WithCountryHeader, the language from
+	// Accept-Language.
+	Conditions map[string][]string
+}
+
+// redirectRule is a RedirectRule with its pattern compiled.
+type redirectRule struct {
+	RedirectRule
+	pattern pathPattern
+}
+
+func compileRedirectRule(r RedirectRule) (redirectRule, error) {
+	if !strings.HasPrefix(r.From, "/") {
+		return redirectRule{}, fmt.Errorf("rule from %q: path must start with /", r.From)
+	}
+	if r.To == "" {
+		return redirectRule{}, fmt.Errorf("rule from %q: missing target", r.From)
+	}
+	if r.Status == 0 {
+		r.Status = consts.StatusMovedPermanently
+	}
+	if r.Status < 100 || r.Status > 599 {
+		return redirectRule{}, fmt.Errorf("rule from %q: invalid status %d", r.From, r.Status)
+	}
+	for key := range r.Conditions {
+		if key != "Country" && key != "Language" {
+			return redirectRule{}, fmt.Errorf("rule from %q: unknown condition %q", r.From, key)
+		}
+	}
+
+	rule := redirectRule{RedirectRule: r, pattern: compilePattern(r.From)}
+	u, err := url.Parse(r.To)
+	if err != nil {
+		return redirectRule{}, fmt.Errorf("rule from %q: invalid target %q", r.From, r.To)
+	}
+	if u.IsAbs() && !rule.isRedirect() {
+		return redirectRule{}, fmt.Errorf("rule from %q: absolute target %q can only be redirected to", r.From, r.To)
+	}
+	return rule, nil
+}
+
+// isRedirect reports whether the rule answers with a redirect rather than
+// serving its target.
+func (r redirectRule) isRedirect() bool {
+	switch r.Status {
+	case consts.StatusMovedPermanently, consts.StatusFound, consts.StatusSeeOther,
+		consts.StatusTemporaryRedirect, consts.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// match reports whether the rule applies to the request for urlPath and
+// returns the placeholders it captured.
+func (r redirectRule) match(c *app.RequestContext, cfg *option, urlPath string) (map[string]string, bool) {
+	params, ok := r.pattern.match(urlPath)
+	if !ok {
+		return nil, false
+	}
+	for key, want := range r.Query {
+		value := c.QueryArgs().Peek(key)
+		if value == nil {
+			return nil, false
+		}
+		if strings.HasPrefix(want, ":") {
+			if !isSegment(string(value)) {
+				return nil, false
+			}
+			if params == nil {
+				params = make(map[string]string)
+			}
+			params[want[1:]] = string(value)
+			continue
+		}
+		if string(value) != want {
+			return nil, false
+		}
+	}
+	if countries, ok := r.Conditions["Country"]; ok {
+		if !matchCountry(countries, string(c.Request.Header.Peek(cfg.countryHeader))) {
+			return nil, false
+		}
+	}
+	if languages, ok := r.Conditions["Language"]; ok {
+		if !matchLanguage(languages, parseAcceptLanguage(string(c.Request.Header.Peek("Accept-Language")))) {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// isSegment reports whether value expands to a single path segment, so
+// that a placeholder captured from the query cannot climb out of the
+// target.
+func isSegment(value string) bool {
+	return value != "." && value != ".." && !strings.ContainsAny(value, "/\\")
+}
+
+func matchCountry(countries []string, country string) bool {
+	for _, want := range countries {
+		if country != "" && strings.EqualFold(want, country) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchLanguage reports whether one of the accepted languages is listed. A
+// listed primary language, such as "en", also matches its regional
+// variants, such as "en-gb".
+func matchLanguage(languages, accepted []string) bool {
+	for _, want := range languages {
+		want = strings.ToLower(want)
+		for _, tag := range accepted {
+			if tag == want || strings.HasPrefix(tag, want+"-") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// redirectResult is the outcome of the redirect rules for a request.
+type redirectResult struct {
+	// target is the expanded To of the matching rule.
+	target string
+	status int
+	// redirect is true if the response is a redirect to target, false if
+	// target is served in place of the requested path.
+	redirect bool
+	// keepQuery is true if the query string of the request is passed on
+	// to the redirect, which is the case unless the rule matched it.
+	keepQuery bool
+}
+
+// evalRedirects returns the result of the first rule matching the request
+// for urlPath. exists reports whether a file exists at the requested path,
+// it is only called for rules that are not forced.
+func evalRedirects(c *app.RequestContext, cfg *option, urlPath string, exists func() bool) (redirectResult, bool) {
+	rules := cfg.redirectRules
+	if cfg.redirectsFile != nil {
+		fileRules, _ := cfg.redirectsFile.load().([]redirectRule)
+		rules = append(rules[:len(rules):len(rules)], fileRules...)
+	}
+
+	checked, found := false, false
+	for _, rule := range rules {
+		params, ok := rule.match(c, cfg, urlPath)
+		if !ok {
+			continue
+		}
+		if !rule.Force {
+			if !checked {
+				checked, found = true, exists()
+			}
+			if found {
+				continue
+			}
+		}
+		return redirectResult{
+			target:    expandParams(rule.To, params),
+			status:    rule.Status,
+			redirect:  rule.isRedirect(),
+			keepQuery: len(rule.Query) == 0,
+		}, true
+	}
+	return redirectResult{}, false
+}
+
+// rewrite returns the request path and the path in the root of a target
+// served in place of the requested path. The target is cleaned, so that
+// it stays under the path prefix.
+func (o *option) rewrite(target string) (urlPath, name string) {
+	urlPath, _, _ = strings.Cut(target, "?")
+	cleaned := path.Clean("/" + urlPath)
+	if strings.HasSuffix(urlPath, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	urlPath = cleaned
+	name = o.pathPrefix + urlPath
+	if len(name) > 1 {
+		name = trimRight(name, '/')
+	}
+	return urlPath, name
+}
+
+// location returns the Location of the redirect for a handler mounted at
+// mount.
+func (r redirectResult) location(c *app.RequestContext, mount string) string {
+	return redirectLocation(c, mount, r.target, r.keepQuery)
+}
+
+// redirectLocation returns the Location of a redirect to target for a
+// handler mounted at mount. The path of a relative target is escaped. If
+// keepQuery is true, the query string of the request is kept unless the
+// target has its own.
+func redirectLocation(c *app.RequestContext, mount, target string, keepQuery bool) string {
+	if u, err := url.Parse(target); err == nil && !u.IsAbs() {
+		p, query, hasQuery := strings.Cut(target, "?")
+		target = (&url.URL{Path: strings.TrimSuffix(mount, "/") + p}).EscapedPath()
+		if hasQuery {
+			target += "?" + query
+		}
+	}
+	if query := c.URI().QueryString(); keepQuery && len(query) > 0 && !strings.Contains(target, "?") {
+		target += "?" + string(query)
+	}
+	return target
+}
+
+// parseRedirectRules parses a _redirects file. Each line holds a rule:
+//
+//	# from [query params] to [status[!]] [conditions]
+//	/old/*          /new/:splat     301
+//	/store id=:id   /blog/:id       302
+//	/app/*          /index.html     200
+//	/               /de/            302!  Language=de Country=de,at
+//
+// A "!" after the status forces the rule.
+func parseRedirectRules(r io.Reader) (interface{}, error) {
+	var rules []redirectRule
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule, err := parseRedirectLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		compiled, err := compileRedirectRule(rule)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		rules = append(rules, compiled)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func parseRedirectLine(line string) (RedirectRule, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return RedirectRule{}, fmt.Errorf("missing target in %q", line)
+	}
+	rule := RedirectRule{From: fields[0]}
+
+	i := 1
+	for ; i < len(fields) && !isTarget(fields[i]); i++ {
+		key, value, ok := strings.Cut(fields[i], "=")
+		if !ok || key == "" {
+			return RedirectRule{}, fmt.Errorf("malformed query parameter %q", fields[i])
+		}
+		if rule.Query == nil {
+			rule.Query = make(map[string]string)
+		}
+		rule.Query[key] = value
+	}
+	if i == len(fields) {
+		return RedirectRule{}, fmt.Errorf("missing target in %q", line)
+	}
+	rule.To = fields[i]
+	i++
+
+	if i < len(fields) && !strings.Contains(fields[i], "=") {
+		status := fields[i]
+		if strings.HasSuffix(status, "!") {
+			rule.Force = true
+			status = strings.TrimSuffix(status, "!")
+		}
+		code, err := strconv.Atoi(status)
+		if err != nil {
+			return RedirectRule{}, fmt.Errorf("invalid status %q", fields[i])
+		}
+		rule.Status = code
+		i++
+	}
+
+	for ; i < len(fields); i++ {
+		key, value, ok := strings.Cut(fields[i], "=")
+		if !ok || key == "" || value == "" {
+			return RedirectRule{}, fmt.Errorf("malformed condition %q", fields[i])
+		}
+		if rule.Conditions == nil {
+			rule.Conditions = make(map[string][]string)
+		}
+		switch strings.ToLower(key) {
+		case "country":
+			key = "Country"
+		case "language":
+			key = "Language"
+		}
+		rule.Conditions[key] = append(rule.Conditions[key], strings.Split(value, ",")...)
+	}
+	return rule, nil
+}
+
+func isTarget(field string) bool {
+	return strings.HasPrefix(field, "/") || strings.HasPrefix(field, "http://") ||
+		strings.HasPrefix(field, "https://")
+}
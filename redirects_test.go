@@ -0,0 +1,301 @@
+package filesystem
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/test/assert"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+func TestParseRedirectLine(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		line    string
+		rule    RedirectRule
+		wantErr bool
+	}{
+		{
+			name: "Should parse a splat redirect",
+			line: "/old/*  /new/:splat  301",
+			rule: RedirectRule{From: "/old/*", To: "/new/:splat", Status: 301},
+		},
+		{
+			name: "Should default the status",
+			line: "/a /b",
+			rule: RedirectRule{From: "/a", To: "/b"},
+		},
+		{
+			name: "Should parse query parameters",
+			line: "/store id=:id /blog/:id 302",
+			rule: RedirectRule{From: "/store", Query: map[string]string{"id": ":id"}, To: "/blog/:id", Status: 302},
+		},
+		{
+			name: "Should parse a forced rewrite",
+			line: "/app/* /index.html 200!",
+			rule: RedirectRule{From: "/app/*", To: "/index.html", Status: 200, Force: true},
+		},
+		{
+			name: "Should parse conditions",
+			line: "/ /de/ 302 Language=de country=de,at",
+			rule: RedirectRule{
+				From: "/", To: "/de/", Status: 302,
+				Conditions: map[string][]string{"Language": {"de"}, "Country": {"de", "at"}},
+			},
+		},
+		{
+			name: "Should parse an absolute target",
+			line: "/docs/* https://docs.example.com/:splat 308",
+			rule: RedirectRule{From: "/docs/*", To: "https://docs.example.com/:splat", Status: 308},
+		},
+		{
+			name:    "Should reject a rule without target",
+			line:    "/a id=1",
+			wantErr: true,
+		},
+		{
+			name:    "Should reject an invalid status",
+			line:    "/a /b moved",
+			wantErr: true,
+		},
+		{
+			name:    "Should reject a malformed condition",
+			line:    "/a /b 302 Language",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			rule, err := parseRedirectLine(tt.line)
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.DeepEqual(t, tt.rule, rule)
+		})
+	}
+}
+
+func TestCompileRedirectRule(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		rule    RedirectRule
+		wantErr bool
+	}{
+		{RedirectRule{From: "/a", To: "/b"}, false},
+		{RedirectRule{From: "a", To: "/b"}, true},
+		{RedirectRule{From: "/a"}, true},
+		{RedirectRule{From: "/a", To: "/b", Status: 999}, true},
+		{RedirectRule{From: "/a", To: "https://example.com/", Status: 200}, true},
+		{RedirectRule{From: "/a", To: "/b", Conditions: map[string][]string{"Role": {"admin"}}}, true},
+	}
+	for _, tt := range tests {
+		_, err := compileRedirectRule(tt.rule)
+		assert.DeepEqual(t, tt.wantErr, err != nil)
+	}
+}
+
+func TestParseRedirectRules(t *testing.T) {
+	t.Parallel()
+
+	value, err := parseRedirectRules(strings.NewReader("# comment\n\n/a /b\n/c /d 302\n"))
+	assert.Nil(t, err)
+	assert.DeepEqual(t, 2, len(value.([]redirectRule)))
+
+	_, err = parseRedirectRules(strings.NewReader("/a /b\n/c\n"))
+	assert.NotNil(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "line 2:"))
+}
+
+func TestRedirects(t *testing.T) {
+	t.Parallel()
+
+	h := server.New()
+	NewFSHandler(h, "/site", http.Dir("./examples/testdata/fs"),
+		WithRedirects(
+			RedirectRule{From: "/old/*", To: "/new/:splat", Status: 301},
+			RedirectRule{From: "/store", Query: map[string]string{"id": ":id"}, To: "/blog/:id", Status: 302},
+			RedirectRule{From: "/docs/*", To: "https://docs.example.com/:splat", Status: 308},
+			RedirectRule{From: "/img/:name", To: "/index.html", Status: 200},
+			RedirectRule{From: "/css/*", To: "/index.html", Status: 200, Force: true},
+			RedirectRule{From: "/gone", To: "/index.html", Status: 410},
+			RedirectRule{
+				From: "/", To: "/de/", Status: 302,
+				Conditions: map[string][]string{"Language": {"de"}},
+			},
+			RedirectRule{
+				From: "/", To: "/fr/", Status: 302,
+				Conditions: map[string][]string{"Country": {"FR"}},
+			},
+		),
+		WithCountryHeader("CF-IPCountry"),
+	)
+
+	tests := []struct {
+		name        string
+		url         string
+		headers     []ut.Header
+		statusCode  int
+		location    string
+		contentType string
+	}{
+		{
+			name:       "Should redirect with the splat",
+			url:        "/site/old/a/b",
+			statusCode: 301,
+			location:   "/site/new/a/b",
+		},
+		{
+			name:       "Should keep the query string",
+			url:        "/site/old/a?x=1",
+			statusCode: 301,
+			location:   "/site/new/a?x=1",
+		},
+		{
+			name:       "Should match query parameters",
+			url:        "/site/store?id=42",
+			statusCode: 302,
+			location:   "/site/blog/42",
+		},
+		{
+			name:       "Should require query parameters",
+			url:        "/site/store",
+			statusCode: 404,
+		},
+		{
+			name:       "Should redirect to an absolute target",
+			url:        "/site/docs/intro",
+			statusCode: 308,
+			location:   "https://docs.example.com/intro",
+		},
+		{
+			name:        "Should rewrite a missing file",
+			url:         "/site/img/missing.png",
+			statusCode:  200,
+			contentType: "text/html",
+		},
+		{
+			name:        "Should not shadow an existing file",
+			url:         "/site/img/fiber.png",
+			statusCode:  200,
+			contentType: "image/png",
+		},
+		{
+			name:        "Should force a rewrite over an existing file",
+			url:         "/site/css/style.css",
+			statusCode:  200,
+			contentType: "text/html",
+		},
+		{
+			name:        "Should serve the target with the rule status",
+			url:         "/site/gone",
+			statusCode:  410,
+			contentType: "text/html",
+		},
+		{
+			name:       "Should match the language",
+			url:        "/site/",
+			headers:    []ut.Header{{Key: "Accept-Language", Value: "fr;q=0.5, de-AT"}},
+			statusCode: 302,
+			location:   "/site/de/",
+		},
+		{
+			name:       "Should match the country",
+			url:        "/site/",
+			headers:    []ut.Header{{Key: "CF-IPCountry", Value: "fr"}},
+			statusCode: 302,
+			location:   "/site/fr/",
+		},
+		{
+			name:        "Should serve the index without matching condition",
+			url:         "/site/",
+			headers:     []ut.Header{{Key: "Accept-Language", Value: "en"}},
+			statusCode:  200,
+			contentType: "text/html",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := ut.PerformRequest(h.Engine, consts.MethodGet, tt.url, nil, tt.headers...)
+			response := w.Result()
+			assert.DeepEqual(t, tt.statusCode, response.StatusCode())
+			if tt.location != "" {
+				assert.DeepEqual(t, tt.location, string(response.Header.Peek("Location")))
+			}
+			if tt.contentType != "" {
+				assert.DeepEqual(t, tt.contentType, response.Header.Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestRewriteStaysUnderPathPrefix(t *testing.T) {
+	t.Parallel()
+
+	h := server.New()
+	NewFSHandler(h, "/rw", http.Dir("./examples/testdata/fs"),
+		WithPathPrefix("img"),
+		WithRedirects(
+			RedirectRule{From: "/dl", Query: map[string]string{"f": ":f"}, To: "/:f", Status: 200},
+			RedirectRule{From: "/dir", Query: map[string]string{"d": ":d"}, To: "/:d/index.html", Status: 200},
+			RedirectRule{From: "/up", To: "/../index.html", Status: 200},
+		),
+	)
+
+	tests := []struct {
+		name        string
+		url         string
+		statusCode  int
+		contentType string
+	}{
+		{
+			name:        "Should expand a query placeholder",
+			url:         "/rw/dl?f=fiber.png",
+			statusCode:  200,
+			contentType: "image/png",
+		},
+		{
+			name:       "Should not expand a query placeholder holding a slash",
+			url:        "/rw/dl?f=../index.html",
+			statusCode: 404,
+		},
+		{
+			name:       "Should not expand a query placeholder holding a backslash",
+			url:        "/rw/dl?f=..%5Cindex.html",
+			statusCode: 404,
+		},
+		{
+			name:       "Should not expand a dot-dot query placeholder",
+			url:        "/rw/dir?d=..",
+			statusCode: 404,
+		},
+		{
+			name:       "Should clean the target under the path prefix",
+			url:        "/rw/up",
+			statusCode: 404,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := ut.PerformRequest(h.Engine, consts.MethodGet, tt.url, nil)
+			response := w.Result()
+			assert.DeepEqual(t, tt.statusCode, response.StatusCode())
+			if tt.contentType != "" {
+				assert.DeepEqual(t, tt.contentType, response.Header.Get("Content-Type"))
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package filesystem
+
+import (
+	"errors"
+	"net/url"
+	"path"
+	"strings"
+)
+
+var (
+	errInvalidEscape    = errors.New("invalid percent-encoding in path")
+	errNULInPath        = errors.New("NUL byte in path")
+	errBackslashInPath  = errors.New("backslash in path")
+	errEncodedDotInPath = errors.New("encoded dot segment in path")
+)
+
+// cleanRequestPath returns the path of a request in the form every handler
+// works with: decoded, starting with "/", without dot segments and keeping
+// its trailing slash.
+//
+// original is the path of the request as it was sent, before hertz decoded
+// it, and decoded is the part of the decoded path relative to the handler.
+// A request path with an invalid escape, a NUL byte, a backslash or an
+// encoded dot segment is rejected, since it is either malformed or an
+// attempt to escape the root.
+//
+// normalize, if not nil, is applied to the cleaned path, for example to
+// normalize it to Unicode NFC.
+func cleanRequestPath(original []byte, decoded string, normalize func(string) string) (string, error) {
+	if err := checkOriginalPath(string(original)); err != nil {
+		return "", err
+	}
+	if strings.IndexByte(decoded, 0) >= 0 {
+		return "", errNULInPath
+	}
+	if strings.IndexByte(decoded, '\\') >= 0 {
+		return "", errBackslashInPath
+	}
+
+	cleaned := path.Clean("/" + decoded)
+	if cleaned != "/" && strings.HasSuffix(decoded, "/") {
+		cleaned += "/"
+	}
+	if normalize != nil {
+		cleaned = normalize(cleaned)
+	}
+	return cleaned, nil
+}
+
+// checkOriginalPath rejects the undecoded request paths whose decoding
+// would be ambiguous or unsafe.
+func checkOriginalPath(original string) error {
+	if strings.IndexByte(original, 0) >= 0 {
+		return errNULInPath
+	}
+	if strings.IndexByte(original, '\\') >= 0 {
+		return errBackslashInPath
+	}
+	if strings.IndexByte(original, '%') < 0 {
+		return nil
+	}
+
+	for _, segment := range strings.Split(original, "/") {
+		if strings.IndexByte(segment, '%') < 0 {
+			continue
+		}
+		unescaped, err := url.PathUnescape(segment)
+		if err != nil {
+			return errInvalidEscape
+		}
+		if strings.IndexByte(unescaped, 0) >= 0 {
+			return errNULInPath
+		}
+		if strings.IndexByte(unescaped, '\\') >= 0 {
+			return errBackslashInPath
+		}
+		for _, part := range strings.Split(unescaped, "/") {
+			if part == "." || part == ".." {
+				return errEncodedDotInPath
+			}
+		}
+	}
+	return nil
+}
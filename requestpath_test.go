@@ -0,0 +1,138 @@
+package filesystem
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/test/assert"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+func TestCleanRequestPath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		original string
+		decoded  string
+		want     string
+		wantErr  error
+	}{
+		{original: "/", decoded: "", want: "/"},
+		{original: "/a%20b.txt", decoded: "a b.txt", want: "/a b.txt"},
+		{original: "//a//b/", decoded: "a//b/", want: "/a/b/"},
+		{original: "/a/./b", decoded: "/a/./b", want: "/a/b"},
+		{original: "/%252e%252e", decoded: "/%2e%2e", want: "/%2e%2e"},
+		{original: "/a/%2e%2e/b", decoded: "/b", wantErr: errEncodedDotInPath},
+		{original: "/a/%2E./b", decoded: "/b", wantErr: errEncodedDotInPath},
+		{original: "/%2e%2e%2fetc", decoded: "/../etc", wantErr: errEncodedDotInPath},
+		{original: "/a%00b", decoded: "/a\x00b", wantErr: errNULInPath},
+		{original: "/a%5cb", decoded: "/a\\b", wantErr: errBackslashInPath},
+		{original: "/a\\b", decoded: "/a\\b", wantErr: errBackslashInPath},
+		{original: "/a%zz", decoded: "/a%zz", wantErr: errInvalidEscape},
+	}
+	for _, tt := range tests {
+		got, err := cleanRequestPath([]byte(tt.original), tt.decoded, nil)
+		assert.DeepEqual(t, tt.wantErr, err)
+		assert.DeepEqual(t, tt.want, got)
+	}
+
+	got, err := cleanRequestPath([]byte("/A"), "/A", strings.ToLower)
+	assert.Nil(t, err)
+	assert.DeepEqual(t, "/a", got)
+}
+
+func TestRequestPathDecoding(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "a b.txt"), []byte("space"), 0o644))
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "café.txt"), []byte("nfc"), 0o644))
+
+	h := server.New()
+	NewFSHandler(h, "/fs", http.Dir(root))
+	h.Use(New("/mw", http.Dir(root)))
+
+	for _, prefix := range []string{"/fs", "/mw"} {
+		w := ut.PerformRequest(h.Engine, consts.MethodGet, prefix+"/a%20b.txt", nil)
+		assert.DeepEqual(t, 200, w.Result().StatusCode())
+		assert.DeepEqual(t, "space", string(w.Result().Body()))
+
+		w = ut.PerformRequest(h.Engine, consts.MethodGet, prefix+"/caf%C3%A9.txt", nil)
+		assert.DeepEqual(t, 200, w.Result().StatusCode())
+
+		w = ut.PerformRequest(h.Engine, consts.MethodGet, prefix+"/x/%2e%2e/a%20b.txt", nil)
+		assert.DeepEqual(t, 400, w.Result().StatusCode())
+
+		w = ut.PerformRequest(h.Engine, consts.MethodGet, prefix+"/a%00b", nil)
+		assert.DeepEqual(t, 400, w.Result().StatusCode())
+	}
+}
+
+func TestRequestPathNFC(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "caf\u00e9.txt"), []byte("nfc"), 0o644))
+
+	h := server.New()
+	NewFSHandler(h, "/fs", http.Dir(root))
+	NewFSHandler(h, "/nfc", http.Dir(root), WithNFC(true))
+	NewFSHandler(h, "/lower", http.Dir(root), WithNFC(true), WithPathNormalizer(strings.ToLower))
+	h.Use(New("/mw", http.Dir(root), WithNFC(true)))
+
+	tests := []struct {
+		url        string
+		statusCode int
+	}{
+		{url: "/fs/cafe%CC%81.txt", statusCode: 404},
+		{url: "/nfc/cafe%CC%81.txt", statusCode: 200},
+		{url: "/nfc/caf%C3%A9.txt", statusCode: 200},
+		{url: "/lower/CAFE%CC%81.TXT", statusCode: 200},
+		{url: "/mw/cafe%CC%81.txt", statusCode: 200},
+	}
+	for _, tt := range tests {
+		w := ut.PerformRequest(h.Engine, consts.MethodGet, tt.url, nil)
+		assert.DeepEqual(t, tt.statusCode, w.Result().StatusCode())
+	}
+}
+
+func FuzzCleanRequestPath(f *testing.F) {
+	for _, seed := range []string{"/", "/a%20b", "/a/%2e%2e/b", "/%2e%2e%2f", "//a/./b/", "/a%00", "/a%5c", "/..", "/%"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, original string) {
+		decoded, err := url.PathUnescape(original)
+		if err != nil {
+			return
+		}
+		got, err := cleanRequestPath([]byte(original), decoded, nil)
+		if err != nil {
+			return
+		}
+		if !strings.HasPrefix(got, "/") {
+			t.Fatalf("%q: %q does not start with /", original, got)
+		}
+		if strings.ContainsAny(got, "\x00\\") {
+			t.Fatalf("%q: %q contains a NUL byte or a backslash", original, got)
+		}
+		for _, segment := range strings.Split(got, "/") {
+			if segment == "." || segment == ".." {
+				t.Fatalf("%q: %q has a dot segment", original, got)
+			}
+		}
+		if trimmed := trimRight(got, '/'); trimmed != "" && path.Clean(trimmed) != trimmed {
+			t.Fatalf("%q: %q is not clean", original, got)
+		}
+		again, err := cleanRequestPath([]byte(got), got, nil)
+		if err == nil && again != got {
+			t.Fatalf("%q: cleaning %q again gives %q", original, got, again)
+		}
+	})
+}
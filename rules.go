@@ -0,0 +1,153 @@
+package filesystem
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+)
+
+// reloadInterval is how often a rules file is checked for changes.
+const reloadInterval = time.Second
+
+// rulesFile is a rules file read from a http.FileSystem, which is parsed
+// again when its size or modification time changes.
+type rulesFile struct {
+	fs     http.FileSystem
+	name   string
+	parse  func(r io.Reader) (interface{}, error)
+	inRoot bool
+
+	mu      sync.Mutex
+	value   interface{}
+	size    int64
+	modTime time.Time
+	checked time.Time
+}
+
+func newRulesFile(fs http.FileSystem, name string, parse func(r io.Reader) (interface{}, error)) *rulesFile {
+	if !strings.HasPrefix(name, "/") {
+		name = "/" + name
+	}
+	return &rulesFile{fs: fs, name: name, parse: parse}
+}
+
+// load returns the parsed rules, reloading them if the file has changed
+// since it was last checked. A missing file yields no rules, while a file
+// that fails to parse keeps the previous rules.
+func (rf *rulesFile) load() interface{} {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(rf.checked) < reloadInterval {
+		return rf.value
+	}
+	rf.checked = now
+
+	f, err := rf.fs.Open(rf.name)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			hlog.SystemLogger().Errorf("failed to open rules file %s: %s", rf.name, err)
+			return rf.value
+		}
+		rf.value, rf.size, rf.modTime = nil, 0, time.Time{}
+		return nil
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		hlog.SystemLogger().Errorf("failed to stat rules file %s: %s", rf.name, err)
+		return rf.value
+	}
+	if rf.value != nil && stat.Size() == rf.size && stat.ModTime().Equal(rf.modTime) {
+		return rf.value
+	}
+
+	value, err := rf.parse(f)
+	if err != nil {
+		hlog.SystemLogger().Errorf("failed to parse rules file %s: %s", rf.name, err)
+		return rf.value
+	}
+	rf.value, rf.size, rf.modTime = value, stat.Size(), stat.ModTime()
+	return value
+}
+
+// pathPattern matches request paths the way _headers and _redirects files
+// do: a ":name" segment matches any single segment and a trailing "*"
+// matches the rest of the path, captured as "splat".
+type pathPattern struct {
+	segments []string
+	splat    bool
+}
+
+func compilePattern(pattern string) pathPattern {
+	pattern = strings.Trim(pattern, "/")
+	var p pathPattern
+	if pattern == "*" || strings.HasSuffix(pattern, "/*") {
+		p.splat = true
+		pattern = strings.TrimSuffix(strings.TrimSuffix(pattern, "*"), "/")
+	}
+	if pattern != "" {
+		p.segments = strings.Split(pattern, "/")
+	}
+	return p
+}
+
+// match reports whether urlPath matches the pattern and returns the values
+// of its placeholders.
+func (p pathPattern) match(urlPath string) (map[string]string, bool) {
+	urlPath = strings.Trim(urlPath, "/")
+	var segments []string
+	if urlPath != "" {
+		segments = strings.Split(urlPath, "/")
+	}
+	if len(segments) < len(p.segments) || (!p.splat && len(segments) != len(p.segments)) {
+		return nil, false
+	}
+
+	var params map[string]string
+	for i, s := range p.segments {
+		if strings.HasPrefix(s, ":") && len(s) > 1 {
+			if params == nil {
+				params = make(map[string]string)
+			}
+			params[s[1:]] = segments[i]
+			continue
+		}
+		if s != segments[i] {
+			return nil, false
+		}
+	}
+	if p.splat {
+		if params == nil {
+			params = make(map[string]string)
+		}
+		params["splat"] = strings.Join(segments[len(p.segments):], "/")
+	}
+	return params, true
+}
+
+// expandParams replaces the ":name" placeholders of s with their values.
+func expandParams(s string, params map[string]string) string {
+	if len(params) == 0 || !strings.Contains(s, ":") {
+		return s
+	}
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	// Longer names first, so that ":id" does not shadow ":idx".
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+	pairs := make([]string, 0, 2*len(names))
+	for _, name := range names {
+		pairs = append(pairs, ":"+name, params[name])
+	}
+	return strings.NewReplacer(pairs...).Replace(s)
+}
@@ -0,0 +1,64 @@
+package filesystem
+
+import (
+	"os"
+	"path"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// spaOption is the history fallback of a single page application.
+type spaOption struct {
+	fallback    string
+	apiPrefixes []string
+}
+
+// isNavigation reports whether the request for urlPath is a browser
+// navigation the fallback should answer: it accepts html, has no file
+// extension and is not under an api prefix.
+func (s *spaOption) isNavigation(c *app.RequestContext, urlPath string) bool {
+	if path.Ext(urlPath) != "" {
+		return false
+	}
+	for _, prefix := range s.apiPrefixes {
+		if urlPath == prefix || strings.HasPrefix(urlPath, prefix+"/") {
+			return false
+		}
+	}
+	if string(c.Request.Header.Peek("Sec-Fetch-Mode")) == "navigate" {
+		return true
+	}
+	for _, accept := range strings.Split(string(c.Request.Header.Peek("Accept")), ",") {
+		mediaType, _, _ := strings.Cut(accept, ";")
+		if strings.TrimSpace(mediaType) == "text/html" {
+			return true
+		}
+	}
+	return false
+}
+
+// openFallback opens the fallback page.
+func (o *option) openFallback() (resolved, error) {
+	file, err := o.open(o.spa.fallback)
+	if err != nil {
+		return resolved{}, err
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return resolved{}, err
+	}
+	if stat.IsDir() {
+		_ = file.Close()
+		return resolved{}, &os.PathError{Op: "open", Path: o.spa.fallback, Err: os.ErrNotExist}
+	}
+	return resolved{file: file, stat: stat, name: o.spa.fallback, fallback: true}, nil
+}
+
+// setFallbackHeaders marks a response serving the fallback page as never
+// fresh, so that a new deployment is picked up on the next navigation.
+func setFallbackHeaders(c *app.RequestContext) {
+	c.Response.Header.Set("Cache-Control", "no-cache")
+	c.Response.Header.Add("Vary", "Accept")
+}
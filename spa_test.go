@@ -0,0 +1,87 @@
+package filesystem
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/test/assert"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+func TestSPA(t *testing.T) {
+	t.Parallel()
+
+	h := server.New()
+	NewFSHandler(h, "/spa", http.Dir("./examples/testdata/fs"),
+		WithSPA("index.html", "/api"),
+		WithMaxAge(3600),
+	)
+	html := ut.Header{Key: "Accept", Value: "text/html,application/xhtml+xml,*/*;q=0.8"}
+
+	tests := []struct {
+		name         string
+		url          string
+		headers      []ut.Header
+		statusCode   int
+		contentType  string
+		cacheControl string
+	}{
+		{
+			name:         "Should serve existing files as usual",
+			url:          "/spa/css/style.css",
+			headers:      []ut.Header{html},
+			statusCode:   200,
+			contentType:  "text/css",
+			cacheControl: "public, max-age=3600",
+		},
+		{
+			name:         "Should serve the fallback for navigations",
+			url:          "/spa/users/42",
+			headers:      []ut.Header{html},
+			statusCode:   200,
+			contentType:  "text/html",
+			cacheControl: "no-cache",
+		},
+		{
+			name:         "Should serve the fallback for navigate fetches",
+			url:          "/spa/users/42",
+			headers:      []ut.Header{{Key: "Sec-Fetch-Mode", Value: "navigate"}},
+			statusCode:   200,
+			contentType:  "text/html",
+			cacheControl: "no-cache",
+		},
+		{
+			name:       "Should not serve the fallback for missing assets",
+			url:        "/spa/static/app.js",
+			headers:    []ut.Header{html},
+			statusCode: 404,
+		},
+		{
+			name:       "Should not serve the fallback for non html requests",
+			url:        "/spa/users/42",
+			headers:    []ut.Header{{Key: "Accept", Value: "application/json"}},
+			statusCode: 404,
+		},
+		{
+			name:       "Should not serve the fallback under api prefixes",
+			url:        "/spa/api/users",
+			headers:    []ut.Header{html},
+			statusCode: 404,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := ut.PerformRequest(h.Engine, consts.MethodGet, tt.url, nil, tt.headers...)
+			response := w.Result()
+			assert.DeepEqual(t, tt.statusCode, response.StatusCode())
+			if tt.contentType != "" {
+				assert.DeepEqual(t, tt.contentType, response.Header.Get("Content-Type"))
+				assert.DeepEqual(t, tt.cacheControl, response.Header.Get("Cache-Control"))
+			}
+		})
+	}
+}
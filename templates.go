@@ -0,0 +1,195 @@
+package filesystem
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// Templates executes the matching files as html/template, set with
+// WithTemplates. Executed files are never answered with 304 Not Modified,
+// their content depending on the request, and are sent with
+// "Cache-Control: private, no-cache" instead of their cache policy.
+type Templates struct {
+	// Patterns are the globs of the files executed, matched like the Glob
+	// of CacheRule. Defaults to "*.html".
+	Patterns []string
+	// Data returns the data the file is executed with for the request,
+	// such as the current user or a CSRF token.
+	Data func(c *app.RequestContext) interface{}
+	// Partials are the globs of the files parsed with every template, for
+	// {{template "name"}}, such as "/partials/*.html". A partial is named
+	// after its path in the root unless it defines its templates itself.
+	// Their directories are listed at most once a second.
+	Partials []string
+	// Funcs are the functions available to the templates.
+	Funcs template.FuncMap
+}
+
+// templatesOption is the Templates of a handler with the templates it
+// parsed.
+type templatesOption struct {
+	Templates
+
+	mu     sync.Mutex
+	parsed map[string]parsedTemplate
+
+	// partialsMu guards the partials listed last, which are listed again
+	// at most every reloadInterval.
+	partialsMu  sync.Mutex
+	names       []string
+	fingerprint string
+	checked     time.Time
+}
+
+// parsedTemplate is a template parsed from a file, valid while the file
+// has the same modification time and size and the partials have not
+// changed.
+type parsedTemplate struct {
+	modTime  time.Time
+	size     int64
+	partials string
+	tmpl     *template.Template
+}
+
+// executes reports whether the file named name is executed.
+func (t *templatesOption) executes(name string) bool {
+	if len(t.Patterns) == 0 {
+		return matchGlob("*.html", name)
+	}
+	for _, glob := range t.Patterns {
+		if matchGlob(glob, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// execute executes the template file named name for the request, parsing
+// it with the partials only if one of them was modified since.
+func (t *templatesOption) execute(c *app.RequestContext, cfg *option, file http.File, name string, stat os.FileInfo) ([]byte, error) {
+	partials, fingerprint, err := t.partials(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	cached, ok := t.parsed[name]
+	t.mu.Unlock()
+	tmpl := cached.tmpl
+	if !ok || !cached.modTime.Equal(stat.ModTime()) || cached.size != stat.Size() || cached.partials != fingerprint {
+		if tmpl, err = t.parse(cfg, file, name, partials); err != nil {
+			return nil, err
+		}
+		t.mu.Lock()
+		t.parsed[name] = parsedTemplate{modTime: stat.ModTime(), size: stat.Size(), partials: fingerprint, tmpl: tmpl}
+		t.mu.Unlock()
+	}
+
+	var data interface{}
+	if t.Data != nil {
+		data = t.Data(c)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// parse parses the template file named name, then its partials.
+func (t *templatesOption) parse(cfg *option, file http.File, name string, partials []string) (*template.Template, error) {
+	src, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New(name).Funcs(t.Funcs).Parse(string(src))
+	if err != nil {
+		return nil, err
+	}
+	for _, partial := range partials {
+		if partial == name {
+			continue
+		}
+		src, err := readFile(cfg, partial)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tmpl.New(strings.TrimPrefix(partial, cfg.pathPrefix)).Parse(string(src)); err != nil {
+			return nil, err
+		}
+	}
+	return tmpl, nil
+}
+
+// partials returns the partials in the root and a fingerprint of their
+// names, modification times and sizes, listing them again if they were
+// last listed more than reloadInterval ago.
+func (t *templatesOption) partials(cfg *option) ([]string, string, error) {
+	t.partialsMu.Lock()
+	defer t.partialsMu.Unlock()
+
+	now := time.Now()
+	if now.Sub(t.checked) < reloadInterval {
+		return t.names, t.fingerprint, nil
+	}
+	names, fingerprint, err := t.listPartials(cfg)
+	if err != nil {
+		return nil, "", err
+	}
+	t.names, t.fingerprint, t.checked = names, fingerprint, now
+	return names, fingerprint, nil
+}
+
+// listPartials lists the partials in the root, and returns a fingerprint
+// of their names, modification times and sizes.
+func (t *templatesOption) listPartials(cfg *option) ([]string, string, error) {
+	var names []string
+	var fingerprint strings.Builder
+	for _, glob := range t.Partials {
+		glob = cfg.pathPrefix + "/" + strings.TrimPrefix(glob, "/")
+		dir, err := cfg.open(path.Dir(glob))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, "", err
+		}
+		infos, err := dir.Readdir(-1)
+		_ = dir.Close()
+		if err != nil {
+			return nil, "", err
+		}
+		sort.Slice(infos, func(i, j int) bool { return infos[i].Name() < infos[j].Name() })
+		for _, info := range infos {
+			if ok, _ := path.Match(path.Base(glob), info.Name()); !ok || info.IsDir() {
+				continue
+			}
+			name := path.Join(path.Dir(glob), info.Name())
+			names = append(names, name)
+			fingerprint.WriteString(name + " " + strconv.FormatInt(info.ModTime().UnixNano(), 10) + " " +
+				strconv.FormatInt(info.Size(), 10) + "\n")
+		}
+	}
+	return names, fingerprint.String(), nil
+}
+
+// readFile reads the file named name in the root.
+func readFile(cfg *option, name string) ([]byte, error) {
+	f, err := cfg.open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
@@ -0,0 +1,123 @@
+package filesystem
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/test/assert"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+func TestTemplates(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	assert.Nil(t, os.MkdirAll(filepath.Join(root, "partials"), 0o755))
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "partials", "header.html"), []byte("<h1>{{.}}</h1>"), 0o644))
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "partials", "footer.html"), []byte(`{{define "footer"}}v{{version}}{{end}}`), 0o644))
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "page.html"), []byte(`{{template "/partials/header.html" .}}{{template "footer"}}`), 0o644))
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "broken.html"), []byte(`{{.Missing.Field}}`), 0o644))
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "plain.txt"), []byte(`{{.}}`), 0o644))
+
+	h := server.New()
+	NewFSHandler(h, "/tpl", http.Dir(root), WithTemplates(Templates{
+		Data:     func(c *app.RequestContext) interface{} { return c.Query("user") },
+		Partials: []string{"/partials/*.html"},
+		Funcs:    map[string]interface{}{"version": func() string { return "1.2" }},
+	}))
+
+	tests := []struct {
+		name       string
+		url        string
+		statusCode int
+		body       string
+	}{
+		{name: "Should execute with the request data", url: "/tpl/page.html?user=ann", statusCode: 200, body: "<h1>ann</h1>v1.2"},
+		{name: "Should escape the data", url: "/tpl/page.html?user=%3Cb%3E", statusCode: 200, body: "<h1>&lt;b&gt;</h1>v1.2"},
+		{name: "Should keep other files cacheable", url: "/tpl/plain.txt", statusCode: 304, body: ""},
+		{name: "Should not expose execution errors", url: "/tpl/broken.html", statusCode: 500, body: "Internal Server Error"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := ut.PerformRequest(h.Engine, consts.MethodGet, tt.url, nil,
+				ut.Header{Key: consts.HeaderIfModifiedSince, Value: time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)})
+			response := w.Result()
+			assert.DeepEqual(t, tt.statusCode, response.StatusCode())
+			assert.DeepEqual(t, tt.body, string(response.Body()))
+		})
+	}
+}
+
+func TestTemplatesCache(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	partial := filepath.Join(root, "name.html")
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "index.html"), []byte(`Hello {{template "/name.html"}}`), 0o644))
+	assert.Nil(t, os.WriteFile(partial, []byte("ann"), 0o644))
+
+	cfg := newOption(http.Dir(root), []Option{WithTemplates(Templates{Partials: []string{"name.html"}})})
+	h := server.New()
+	Register(h, "/tpl", newHandler(cfg))
+	get := func() string {
+		response := ut.PerformRequest(h.Engine, consts.MethodGet, "/tpl/", nil).Result()
+		assert.DeepEqual(t, "", string(response.Header.Peek(consts.HeaderLastModified)))
+		return string(response.Body())
+	}
+	assert.DeepEqual(t, "Hello ann", get())
+
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	assert.Nil(t, os.WriteFile(partial, []byte("bob"), 0o644))
+	assert.Nil(t, os.Chtimes(partial, modTime, modTime))
+	assert.DeepEqual(t, "Hello ann", get())
+	cfg.templates.checked = time.Time{}
+	assert.DeepEqual(t, "Hello bob", get())
+	assert.Nil(t, os.WriteFile(partial, []byte("eve"), 0o644))
+	assert.Nil(t, os.Chtimes(partial, modTime, modTime))
+	cfg.templates.checked = time.Time{}
+	assert.DeepEqual(t, "Hello bob", get())
+}
+
+func TestTemplatesCacheControl(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "page.html"), []byte(`X-User: {{.}}`), 0o644))
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "plain.txt"), []byte("static"), 0o644))
+
+	h := server.New()
+	NewFSHandler(h, "/tpl", http.Dir(root), WithMaxAge(3600),
+		WithCacheRules(CacheRule{Glob: "*.html", Policy: CachePolicy{Public: true, MaxAge: time.Hour, Expires: true}}),
+		WithTemplates(Templates{Data: func(c *app.RequestContext) interface{} { return c.Query("user") }}))
+
+	tests := []struct {
+		name         string
+		method       string
+		url          string
+		cacheControl string
+	}{
+		{name: "Should not let shared caches store executed files", method: consts.MethodGet, url: "/tpl/page.html?user=alice", cacheControl: "private, no-cache"},
+		{name: "Should send the same policy to HEAD requests", method: consts.MethodHead, url: "/tpl/page.html?user=alice", cacheControl: "private, no-cache"},
+		{name: "Should keep the policy of other files", method: consts.MethodGet, url: "/tpl/plain.txt", cacheControl: "public, max-age=3600"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			response := ut.PerformRequest(h.Engine, tt.method, tt.url, nil).Result()
+			assert.DeepEqual(t, 200, response.StatusCode())
+			assert.DeepEqual(t, tt.cacheControl, string(response.Header.Peek("Cache-Control")))
+			if tt.cacheControl == "private, no-cache" {
+				assert.DeepEqual(t, "", string(response.Header.Peek("Expires")))
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package filesystem
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// tryFile is a candidate of the try files chain.
+type tryFile struct {
+	// path is the candidate in the root, in which "$uri" stands for the
+	// requested path. An empty path ends the chain with status.
+	path string
+	// status of the response serving the candidate, zero to keep the
+	// status of the request.
+	status int
+}
+
+// parseTryFile parses a candidate of WithTryFiles: a path optionally
+// followed by "=code", or a lone "=code" ending the chain.
+func parseTryFile(s string) (tryFile, error) {
+	fields := strings.Fields(s)
+	var tf tryFile
+	switch {
+	case len(fields) == 1 && strings.HasPrefix(fields[0], "="):
+	case len(fields) == 1:
+		tf.path = fields[0]
+	case len(fields) == 2 && strings.HasPrefix(fields[1], "="):
+		tf.path = fields[0]
+		fields = fields[1:]
+	default:
+		return tryFile{}, fmt.Errorf("malformed candidate %q", s)
+	}
+	if tf.path != "" && !strings.HasPrefix(tf.path, "/") && !strings.HasPrefix(tf.path, "$uri") {
+		return tryFile{}, fmt.Errorf("candidate %q must start with / or $uri", s)
+	}
+	if strings.HasPrefix(fields[0], "=") {
+		code, err := strconv.Atoi(fields[0][1:])
+		if err != nil || code < 100 || code > 599 {
+			return tryFile{}, fmt.Errorf("invalid status in candidate %q", s)
+		}
+		tf.status = code
+	}
+	return tf, nil
+}
+
+// resolved is what a request resolved to through the try files chain.
+type resolved struct {
+	// file is nil if the chain ended with a status.
+	file http.File
+	stat os.FileInfo
+	// name is the path of file in the root.
+	name   string
+	status int
+	// via is the candidate the request resolved through.
+	via string
+	// dir is true if file is a directory or its index file.
+	dir bool
+	// fallback is true if file is the history fallback of WithSPA.
+	fallback bool
+	// folded is the path in the root the requested path resolved to
+	// case-insensitively, if it did not exist as is.
+	folded string
+	// lang is the language of file if it is a localized variant.
+	lang string
+}
+
+// resolve walks the try files chain for the requested path name and
+// returns the first candidate found. The index file is served in place of
+// a directory. A directory without index is only returned if browsing is
+// enabled or if no other candidate is found. The variants of the files in
+// langs, as negotiated by negotiateLanguages, are preferred to the files.
+func (o *option) resolve(name string, langs []string) (resolved, error) {
+	var dir *resolved
+	for _, tf := range o.tryFiles {
+		if tf.path == "" {
+			if tf.status == http.StatusNotFound {
+				break
+			}
+			if dir != nil {
+				_ = dir.file.Close()
+			}
+			return resolved{status: tf.status}, nil
+		}
+
+		candidate := strings.ReplaceAll(tf.path, "$uri", name)
+		dirOnly := len(candidate) > 1 && strings.HasSuffix(candidate, "/")
+		if len(candidate) > 1 {
+			candidate = trimRight(candidate, '/')
+		}
+		file, localized, lang, err := o.openLocalized(candidate, langs)
+		folded := ""
+		if err != nil && os.IsNotExist(err) && o.caseIndex != nil && trimRight(tf.path, '/') == "$uri" {
+			if name, ok := o.caseIndex.lookup(o, candidate); ok {
+				file, localized, lang, err = o.openLocalized(name, langs)
+				candidate, folded = name, name
+			}
+		}
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return resolved{}, err
+		}
+		stat, err := file.Stat()
+		if err != nil {
+			_ = file.Close()
+			return resolved{}, err
+		}
+
+		if !stat.IsDir() {
+			if dirOnly {
+				_ = file.Close()
+				continue
+			}
+			if dir != nil {
+				_ = dir.file.Close()
+			}
+			return resolved{
+				file: file, stat: stat, name: localized, status: tf.status, via: tf.path, folded: folded, lang: lang,
+			}, nil
+		}
+
+		for _, indexName := range o.indexNames() {
+			indexPath := trimRight(candidate, '/') + indexName
+			if index, localized, lang, err := o.openLocalized(indexPath, langs); err == nil {
+				if indexStat, err := index.Stat(); err == nil && !indexStat.IsDir() {
+					_ = file.Close()
+					if dir != nil {
+						_ = dir.file.Close()
+					}
+					return resolved{
+						file: index, stat: indexStat, name: localized, status: tf.status, via: tf.path, folded: folded, dir: true,
+						lang: lang,
+					}, nil
+				}
+				_ = index.Close()
+			}
+		}
+		if o.browse {
+			return resolved{file: file, stat: stat, name: candidate, status: tf.status, via: tf.path, folded: folded, dir: true}, nil
+		}
+		if dir != nil {
+			_ = file.Close()
+			continue
+		}
+		dir = &resolved{file: file, stat: stat, name: candidate, status: tf.status, via: tf.path, folded: folded, dir: true}
+	}
+	if dir != nil {
+		return *dir, nil
+	}
+	return resolved{}, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+}
+
+// indexNames returns the index files served in place of a directory, in
+// order of preference.
+func (o *option) indexNames() []string {
+	if o.markdown != nil && o.markdown.DirectoryIndex {
+		return []string{o.index, "/README.md"}
+	}
+	return []string{o.index}
+}
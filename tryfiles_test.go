@@ -0,0 +1,120 @@
+package filesystem
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/test/assert"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+func TestParseTryFile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input   string
+		want    tryFile
+		wantErr bool
+	}{
+		{input: "$uri", want: tryFile{path: "$uri"}},
+		{input: "$uri/", want: tryFile{path: "$uri/"}},
+		{input: "/404.html =404", want: tryFile{path: "/404.html", status: 404}},
+		{input: "=403", want: tryFile{status: 403}},
+		{input: "404.html", wantErr: true},
+		{input: "/404.html 404", wantErr: true},
+		{input: "=abc", wantErr: true},
+		{input: "/a =200 =404", wantErr: true},
+	}
+	for _, tt := range tests {
+		tf, err := parseTryFile(tt.input)
+		assert.DeepEqual(t, tt.wantErr, err != nil)
+		assert.DeepEqual(t, tt.want, tf)
+	}
+}
+
+func TestTryFiles(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	files := map[string]string{
+		"about.html":      "about",
+		"docs/index.html": "docs",
+		"img/logo.png":    "png",
+		"404.html":        "not found",
+	}
+	for name, content := range files {
+		name = filepath.Join(root, filepath.FromSlash(name))
+		assert.Nil(t, os.MkdirAll(filepath.Dir(name), 0o755))
+		assert.Nil(t, os.WriteFile(name, []byte(content), 0o644))
+	}
+
+	h := server.New()
+	NewFSHandler(h, "/try", http.Dir(root), WithTryFiles("$uri", "$uri.html", "$uri/", "/404.html =404"))
+	NewFSHandler(h, "/strict", http.Dir(root), WithTryFiles("$uri", "=403"))
+	NewFSHandler(h, "/spa", http.Dir(root), WithNotFoundFile("404.html"))
+
+	tests := []struct {
+		name       string
+		url        string
+		statusCode int
+		body       string
+	}{
+		{
+			name:       "Should serve the file itself",
+			url:        "/try/about.html",
+			statusCode: 200,
+			body:       "about",
+		},
+		{
+			name:       "Should try the html extension",
+			url:        "/try/about",
+			statusCode: 200,
+			body:       "about",
+		},
+		{
+			name:       "Should serve the index of a directory",
+			url:        "/try/docs",
+			statusCode: 200,
+			body:       "docs",
+		},
+		{
+			name:       "Should serve the fallback with its status",
+			url:        "/try/missing",
+			statusCode: 404,
+			body:       "not found",
+		},
+		{
+			name:       "Should skip a directory without index",
+			url:        "/try/img",
+			statusCode: 404,
+			body:       "not found",
+		},
+		{
+			name:       "Should end the chain with a status",
+			url:        "/strict/missing",
+			statusCode: 403,
+		},
+		{
+			name:       "Should try the not found file last",
+			url:        "/spa/img",
+			statusCode: 200,
+			body:       "not found",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := ut.PerformRequest(h.Engine, consts.MethodGet, tt.url, nil)
+			response := w.Result()
+			assert.DeepEqual(t, tt.statusCode, response.StatusCode())
+			if tt.body != "" {
+				assert.DeepEqual(t, tt.body, string(response.Body()))
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/cloudwego/hertz/pkg/app"
@@ -23,6 +24,51 @@ func trimRight(s string, cutset byte) string {
 	return s[:lenStr]
 }
 
+// parseAcceptLanguage returns the language tags of an Accept-Language
+// header, lower cased and sorted by decreasing quality.
+func parseAcceptLanguage(header string) []string {
+	type tag struct {
+		name string
+		q    float64
+	}
+	var tags []tag
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		q := 1.0
+		if params = strings.TrimSpace(params); strings.HasPrefix(params, "q=") {
+			v, err := strconv.ParseFloat(strings.TrimPrefix(params, "q="), 64)
+			if err != nil {
+				continue
+			}
+			q = v
+		}
+		if q <= 0 {
+			continue
+		}
+		tags = append(tags, tag{name: name, q: q})
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	names := make([]string, len(tags))
+	for i, t := range tags {
+		names[i] = t.name
+	}
+	return names
+}
+
+// isHTML reports whether the file named name is an HTML page.
+func isHTML(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".html", ".htm":
+		return true
+	}
+	return false
+}
+
 func getFileExtension(p string) string {
 	n := strings.LastIndexByte(p, '.')
 	if n < 0 {
@@ -31,17 +77,23 @@ func getFileExtension(p string) string {
 	return p[n:]
 }
 
-func dirList(c *app.RequestContext, f http.File) error {
-	fileinfos, err := f.Readdir(-1)
-	if err != nil {
-		return fmt.Errorf("failed to read dir: %w", err)
+func dirList(c *app.RequestContext, f http.File, mounted []os.FileInfo) error {
+	var fileinfos []os.FileInfo
+	if f != nil {
+		var err error
+		if fileinfos, err = f.Readdir(-1); err != nil {
+			return fmt.Errorf("failed to read dir: %w", err)
+		}
 	}
-	fm := make(map[string]os.FileInfo, len(fileinfos))
-	filenames := make([]string, 0, len(fileinfos))
-	for _, fi := range fileinfos {
+	fm := make(map[string]os.FileInfo, len(fileinfos)+len(mounted))
+	filenames := make([]string, 0, len(fileinfos)+len(mounted))
+	// Mount points shadow the entries of the same name
+	for _, fi := range append(fileinfos, mounted...) {
 		name := fi.Name()
+		if _, ok := fm[name]; !ok {
+			filenames = append(filenames, name)
+		}
 		fm[name] = fi
-		filenames = append(filenames, name)
 	}
 
 	basePathEscaped := html.EscapeString(string(c.Path()))
@@ -76,13 +128,8 @@ func getMIME(extension string) string {
 	if len(extension) == 0 {
 		return ""
 	}
-	var mime string
-	if extension[0] == '.' {
-		mime = mimeExtensions[extension[1:]]
-	} else {
-		mime = mimeExtensions[extension]
-	}
-	if len(mime) == 0 {
+	mime, ok := DefaultMIMERegistry.Lookup(extension)
+	if !ok {
 		return MIMEOctetStream
 	}
 	return mime
@@ -91,6 +138,7 @@ func getMIME(extension string) string {
 // MIME types were copied from https://github.com/nginx/nginx/blob/67d2a9541826ecd5db97d604f23460210fd3e517/conf/mime.types with the following updates:
 // - Use "application/xml" instead of "text/xml" as recommended per https://datatracker.ietf.org/doc/html/rfc7303#section-4.1
 // - Use "text/javascript" instead of "application/javascript" as recommended per https://www.rfc-editor.org/rfc/rfc9239#name-text-javascript
+// - Add the common web types the nginx table lacks
 var mimeExtensions = map[string]string{
 	"html":    "text/html",
 	"htm":     "text/html",
@@ -202,4 +250,47 @@ var mimeExtensions = map[string]string{
 	"asf":     "video/x-ms-asf",
 	"wmv":     "video/x-ms-wmv",
 	"avi":     "video/x-msvideo",
+
+	// Types missing from the nginx table
+	"mjs":         "text/javascript",
+	"map":         "application/json",
+	"webmanifest": "application/manifest+json",
+	"jsonld":      "application/ld+json",
+	"csv":         "text/csv",
+	"md":          "text/markdown",
+	"markdown":    "text/markdown",
+	"yaml":        "application/yaml",
+	"yml":         "application/yaml",
+	"toml":        "application/toml",
+	"ics":         "text/calendar",
+	"vtt":         "text/vtt",
+	"srt":         "application/x-subrip",
+	"avifs":       "image/avif-sequence",
+	"apng":        "image/apng",
+	"heic":        "image/heic",
+	"heif":        "image/heif",
+	"jxl":         "image/jxl",
+	"otf":         "font/otf",
+	"ttf":         "font/ttf",
+	"glb":         "model/gltf-binary",
+	"gltf":        "model/gltf+json",
+	"stl":         "model/stl",
+	"obj":         "model/obj",
+	"usdz":        "model/vnd.usdz+zip",
+	"opus":        "audio/opus",
+	"flac":        "audio/flac",
+	"wav":         "audio/wav",
+	"aac":         "audio/aac",
+	"oga":         "audio/ogg",
+	"weba":        "audio/webm",
+	"ogv":         "video/ogg",
+	"mkv":         "video/x-matroska",
+	"mpd":         "application/dash+xml",
+	"gz":          "application/gzip",
+	"tar":         "application/x-tar",
+	"bz2":         "application/x-bzip2",
+	"xz":          "application/x-xz",
+	"zst":         "application/zstd",
+	"epub":        "application/epub+zip",
+	"sh":          "application/x-sh",
 }
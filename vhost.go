@@ -0,0 +1,132 @@
+package filesystem
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/cloudwego/hertz/pkg/route"
+)
+
+// VirtualHost is the root and the options served for a host of
+// VirtualHosts.
+type VirtualHost struct {
+	Root    http.FileSystem
+	Options []Option
+}
+
+// VirtualHosts maps the Host header of requests to the roots they are
+// served from. Hosts are either names, such as "example.com", or wildcards
+// matching any subdomain, such as "*.example.com". A name wins over a
+// wildcard, and a longer wildcard over a shorter one.
+//
+// The hosts can be replaced with Reload while requests are being served.
+type VirtualHosts struct {
+	mu    sync.RWMutex
+	table *hostTable
+}
+
+// hostTable is the compiled form of the hosts of VirtualHosts.
+type hostTable struct {
+	names map[string]*option
+	// wildcards are sorted from the longest suffix to the shortest.
+	wildcards []wildcardHost
+	fallback  *option
+}
+
+type wildcardHost struct {
+	// suffix is the wildcard without its "*", such as ".example.com".
+	suffix string
+	cfg    *option
+}
+
+// NewVirtualHosts creates the virtual hosts serving hosts. Requests for a
+// host matching none of them are served by defaultHost, one of the hosts,
+// or get a 404 if defaultHost is empty.
+func NewVirtualHosts(hosts map[string]VirtualHost, defaultHost string) *VirtualHosts {
+	v := &VirtualHosts{}
+	v.Reload(hosts, defaultHost)
+	return v
+}
+
+// Reload replaces the hosts and the default host. Requests being served
+// finish with the hosts they started with. Invalid hosts are logged and
+// skipped.
+func (v *VirtualHosts) Reload(hosts map[string]VirtualHost, defaultHost string) {
+	table := &hostTable{names: make(map[string]*option, len(hosts))}
+	for host, vh := range hosts {
+		cfg := newOption(vh.Root, vh.Options)
+		key := normalizeHost(host)
+		switch {
+		case strings.HasPrefix(key, "*.") && !strings.Contains(key[1:], "*"):
+			table.wildcards = append(table.wildcards, wildcardHost{suffix: key[1:], cfg: cfg})
+		case key != "" && !strings.Contains(key, "*"):
+			table.names[key] = cfg
+		default:
+			hlog.SystemLogger().Errorf("Invalid virtual host %q", host)
+			continue
+		}
+		if key == normalizeHost(defaultHost) {
+			table.fallback = cfg
+		}
+	}
+	if defaultHost != "" && table.fallback == nil {
+		hlog.SystemLogger().Errorf("Default virtual host %q is not one of the hosts", defaultHost)
+	}
+	sort.Slice(table.wildcards, func(i, j int) bool {
+		return len(table.wildcards[i].suffix) > len(table.wildcards[j].suffix)
+	})
+
+	v.mu.Lock()
+	v.table = table
+	v.mu.Unlock()
+}
+
+// lookup returns the options of the host serving requests for host, or
+// nil if there is none.
+func (v *VirtualHosts) lookup(host string) *option {
+	v.mu.RLock()
+	table := v.table
+	v.mu.RUnlock()
+
+	host = normalizeHost(host)
+	if cfg, ok := table.names[host]; ok {
+		return cfg
+	}
+	for _, w := range table.wildcards {
+		if len(host) > len(w.suffix) && strings.HasSuffix(host, w.suffix) {
+			return w.cfg
+		}
+	}
+	return table.fallback
+}
+
+// normalizeHost returns host lower cased, without port and trailing dot.
+func normalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// NewVirtualHostHandler serves the virtual hosts under relpath, like
+// NewFSHandler serves a single root. Like Register, it can register on
+// route groups.
+func NewVirtualHostHandler(router route.IRoutes, relpath string, hosts *VirtualHosts) {
+	logicFunc := func(ctx context.Context, c *app.RequestContext) {
+		cfg := hosts.lookup(string(c.Request.Header.Host()))
+		if cfg == nil {
+			abortWithError(ctx, c, nil, consts.StatusNotFound)
+			return
+		}
+		relPath := c.Param("filepath")
+		serve(ctx, c, cfg, routeMount(c, relPath), relPath)
+	}
+	Register(router, relpath, logicFunc)
+}
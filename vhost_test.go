@@ -0,0 +1,110 @@
+package filesystem
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/test/assert"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+func newHostRoot(t *testing.T, content string) http.FileSystem {
+	root := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "index.html"), []byte(content), 0o644))
+	return http.Dir(root)
+}
+
+func TestVirtualHosts(t *testing.T) {
+	t.Parallel()
+
+	hosts := NewVirtualHosts(map[string]VirtualHost{
+		"example.com":        {Root: newHostRoot(t, "apex")},
+		"*.example.com":      {Root: newHostRoot(t, "wildcard")},
+		"*.docs.example.com": {Root: newHostRoot(t, "docs")},
+		"shop.example.com":   {Root: newHostRoot(t, "shop"), Options: []Option{WithMaxAge(60)}},
+	}, "example.com")
+
+	h := server.New()
+	NewVirtualHostHandler(h, "/site", hosts)
+
+	tests := []struct {
+		name         string
+		host         string
+		body         string
+		cacheControl string
+	}{
+		{name: "Should serve a host by name", host: "example.com", body: "apex"},
+		{name: "Should ignore the port and case", host: "Example.COM:8080", body: "apex"},
+		{name: "Should serve a wildcard host", host: "blog.example.com", body: "wildcard"},
+		{name: "Should prefer the longest wildcard", host: "v1.docs.example.com", body: "docs"},
+		{name: "Should prefer a name to a wildcard", host: "shop.example.com", body: "shop", cacheControl: "public, max-age=60"},
+		{name: "Should serve the default host", host: "unknown.org", body: "apex"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := ut.PerformRequest(h.Engine, consts.MethodGet, "/site/", nil, ut.Header{Key: "Host", Value: tt.host})
+			response := w.Result()
+			assert.DeepEqual(t, consts.StatusOK, response.StatusCode())
+			assert.DeepEqual(t, tt.body, string(response.Body()))
+			if tt.cacheControl != "" {
+				assert.DeepEqual(t, tt.cacheControl, string(response.Header.Peek("Cache-Control")))
+			}
+		})
+	}
+}
+
+func TestVirtualHostsReload(t *testing.T) {
+	t.Parallel()
+
+	hosts := NewVirtualHosts(map[string]VirtualHost{"a.test": {Root: newHostRoot(t, "a")}}, "")
+	h := server.New()
+	NewVirtualHostHandler(h, "", hosts)
+
+	get := func(host string) (int, string) {
+		w := ut.PerformRequest(h.Engine, consts.MethodGet, "/", nil, ut.Header{Key: "Host", Value: host})
+		return w.Result().StatusCode(), string(w.Result().Body())
+	}
+
+	status, body := get("a.test")
+	assert.DeepEqual(t, consts.StatusOK, status)
+	assert.DeepEqual(t, "a", body)
+	status, _ = get("b.test")
+	assert.DeepEqual(t, consts.StatusNotFound, status)
+
+	hosts.Reload(map[string]VirtualHost{"b.test": {Root: newHostRoot(t, "b")}}, "b.test")
+	status, body = get("b.test")
+	assert.DeepEqual(t, consts.StatusOK, status)
+	assert.DeepEqual(t, "b", body)
+	_, body = get("a.test")
+	assert.DeepEqual(t, "b", body)
+}
+
+func TestVirtualHostHandlerGroup(t *testing.T) {
+	t.Parallel()
+
+	hosts := NewVirtualHosts(map[string]VirtualHost{"a.test": {
+		Root:    newHostRoot(t, "a"),
+		Options: []Option{WithRedirects(RedirectRule{From: "/old", To: "/", Status: 302})},
+	}}, "")
+	h := server.New()
+	group := h.Group("/g", func(ctx context.Context, c *app.RequestContext) {
+		c.Response.Header.Set("X-Group", "g")
+		c.Next(ctx)
+	})
+	NewVirtualHostHandler(group, "/site", hosts)
+
+	w := ut.PerformRequest(h.Engine, consts.MethodGet, "/g/site/", nil, ut.Header{Key: "Host", Value: "a.test"})
+	assert.DeepEqual(t, consts.StatusOK, w.Result().StatusCode())
+	assert.DeepEqual(t, "a", string(w.Result().Body()))
+	assert.DeepEqual(t, "g", w.Result().Header.Get("X-Group"))
+	w = ut.PerformRequest(h.Engine, consts.MethodGet, "/g/site/old", nil, ut.Header{Key: "Host", Value: "a.test"})
+	assert.DeepEqual(t, "/g/site/", string(w.Result().Header.Peek("Location")))
+}
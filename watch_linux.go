@@ -0,0 +1,115 @@
+//go:build linux
+
+package filesystem
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+)
+
+const inotifyMask = syscall.IN_CLOSE_WRITE | syscall.IN_CREATE | syscall.IN_DELETE |
+	syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_DELETE_SELF
+
+// inotifyWatcher watches the directories of a tree with inotify.
+type inotifyWatcher struct {
+	fd int
+	// file is fd in the poller of the runtime, so that closing it stops
+	// the blocked reads.
+	file *os.File
+	root string
+
+	mu sync.Mutex
+	// dirs are the directories watched, by watch descriptor.
+	dirs map[int32]string
+}
+
+// watchDir reports the files changed in dir with inotify, falling back to
+// polling it every interval if inotify is not available, until stop is
+// called.
+func watchDir(dir string, interval time.Duration, changed func(name string)) (stop func(), err error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		hlog.SystemLogger().Warnf("Polling %s, inotify is not available: %s", dir, err)
+		done := make(chan struct{})
+		go pollDir(dir, interval, changed, done)
+		return func() { close(done) }, nil
+	}
+	w := &inotifyWatcher{fd: fd, file: os.NewFile(uintptr(fd), "inotify"), root: dir, dirs: make(map[int32]string)}
+	if err := w.addTree(dir); err != nil {
+		_ = w.file.Close()
+		return nil, err
+	}
+	go w.run(changed)
+	return func() { _ = w.file.Close() }, nil
+}
+
+// addTree watches dir and the directories under it.
+func (w *inotifyWatcher) addTree(dir string) error {
+	return filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			// The root must exist, the directories under it may be gone
+			if p == w.root {
+				return err
+			}
+			return nil
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		wd, err := syscall.InotifyAddWatch(w.fd, p, inotifyMask)
+		if err != nil {
+			return err
+		}
+		w.mu.Lock()
+		w.dirs[int32(wd)] = p
+		w.mu.Unlock()
+		return nil
+	})
+}
+
+// run reads the events of the watcher until it is stopped or fails.
+func (w *inotifyWatcher) run(changed func(name string)) {
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		n, err := w.file.Read(buf)
+		if errors.Is(err, os.ErrClosed) {
+			return
+		}
+		if err != nil || n <= 0 {
+			hlog.SystemLogger().Errorf("Stopped watching %s: %v", w.root, err)
+			_ = w.file.Close()
+			return
+		}
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameStart := offset + syscall.SizeofInotifyEvent
+			offset = nameStart + int(event.Len)
+			name := strings.TrimRight(string(buf[nameStart:offset]), "\x00")
+
+			w.mu.Lock()
+			dir, ok := w.dirs[event.Wd]
+			if event.Mask&syscall.IN_IGNORED != 0 {
+				delete(w.dirs, event.Wd)
+			}
+			w.mu.Unlock()
+			if !ok || name == "" {
+				continue
+			}
+			p := filepath.Join(dir, name)
+			if event.Mask&syscall.IN_ISDIR != 0 && event.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+				if err := w.addTree(p); err != nil {
+					hlog.SystemLogger().Warnf("Failed to watch %s: %s", p, err)
+				}
+			}
+			changed(rootName(w.root, p))
+		}
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package filesystem
+
+import "time"
+
+// watchDir reports the files changed in dir by polling it every interval,
+// until stop is called.
+func watchDir(dir string, interval time.Duration, changed func(name string)) (stop func(), err error) {
+	done := make(chan struct{})
+	go pollDir(dir, interval, changed, done)
+	return func() { close(done) }, nil
+}